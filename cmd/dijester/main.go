@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
@@ -14,10 +17,12 @@ import (
 	"github.com/shrik450/dijester/pkg/config"
 	"github.com/shrik450/dijester/pkg/constants"
 	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/filter"
 	"github.com/shrik450/dijester/pkg/formatter"
 	"github.com/shrik450/dijester/pkg/models"
 	"github.com/shrik450/dijester/pkg/processor"
 	"github.com/shrik450/dijester/pkg/source"
+	"github.com/shrik450/dijester/pkg/state"
 )
 
 func main() {
@@ -61,21 +66,60 @@ func main() {
 		Articles:    make([]*models.Article, 0),
 	}
 
+	var runState *state.State
+	var statePath string
+	if cfg.Digest.StatePath != "" {
+		statePath = resolvePath(cfg.Digest.StatePath, *outputDir)
+		runState, err = state.LoadFile(statePath)
+		if err != nil {
+			log.Fatalf("Error loading state: %v", err)
+		}
+		log.Printf("Loaded state from %s", statePath)
+	}
+	fetchedSources := make([]string, 0, len(cfg.Sources))
+	var dropped []droppedArticle
+
 	globalFetcher := fetcher.FromConfig(cfg.FetcherConfig)
 	globalProcs, globalProcsOpts, err := processor.InitializeProcessors(cfg.ProcessorConfig)
 	if err != nil {
 		log.Fatalf("Error initializing global processors: %v", err)
 	}
 
+	digestProcs := make([]processor.DigestProcessor, 0)
+	digestProcsOpts := make([]processor.Options, 0)
+	addDigestProcs := func(procs []processor.Processor, opts []processor.Options) {
+		for i, proc := range procs {
+			digestProc, ok := proc.(processor.DigestProcessor)
+			if ok && !slices.Contains(digestProcs, digestProc) {
+				digestProcs = append(digestProcs, digestProc)
+				digestProcsOpts = append(digestProcsOpts, opts[i])
+			}
+		}
+	}
+
 	outputFormatter, err := formatter.New(cfg.Digest.Format)
 	if err != nil {
 		log.Fatalf("Error initializing formatter: %v", err)
 	}
-	fmtOpts := formatter.OptionsFromConfig(cfg.Formatting, globalFetcher)
+	fmtOpts, err := formatter.OptionsFromConfig(cfg.Formatting, globalFetcher)
+	if err != nil {
+		log.Fatalf("Error parsing formatting options: %v", err)
+	}
+
+	digestFilter, err := filter.Compile(cfg.Digest.Filter)
+	if err != nil {
+		log.Fatalf("Error compiling digest filter: %v", err)
+	}
 
-	ctx := context.Background()
+	// Interrupting cancels fetching and processing.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	for srcName, srcCfg := range cfg.Sources {
+		if ctx.Err() != nil {
+			break
+		}
+
 		if !srcCfg.Enabled {
 			log.Println("Skipping disabled source: ", srcName)
 			continue
@@ -93,6 +137,18 @@ func main() {
 			continue
 		}
 
+		srcFilter, err := filter.Compile(srcCfg.Filter)
+		if err != nil {
+			log.Printf("Error compiling filter for source %s: %v", srcName, err)
+			continue
+		}
+
+		srcWordFilter, err := srcCfg.WordFilter()
+		if err != nil {
+			log.Printf("Error compiling word lists for source %s: %v", srcName, err)
+			continue
+		}
+
 		var srcFetcher fetcher.Fetcher
 		if srcCfg.FetcherConfig != nil {
 			srcFetcher = fetcher.FromConfig(*srcCfg.FetcherConfig)
@@ -103,7 +159,9 @@ func main() {
 		var srcProcs []processor.Processor
 		var srcProcsOpts []processor.Options
 		if srcCfg.ProcessorConfig != nil {
-			srcProcs, srcProcsOpts, err = processor.InitializeProcessors(*srcCfg.ProcessorConfig)
+			srcProcs, srcProcsOpts, err = processor.InitializeProcessors(
+				srcCfg.ProcessorConfig.Inherit(cfg.ProcessorConfig),
+			)
 			if err != nil {
 				log.Printf("Error initializing processors for source %s: %v", srcName, err)
 				continue
@@ -112,6 +170,7 @@ func main() {
 			srcProcs = globalProcs
 			srcProcsOpts = globalProcsOpts
 		}
+		addDigestProcs(srcProcs, srcProcsOpts)
 
 		articles, err := src.Fetch(ctx, srcFetcher)
 		if err != nil {
@@ -119,37 +178,152 @@ func main() {
 			continue
 		}
 		log.Printf("Fetched %d articles from %s", len(articles), src.Name())
+		fetchedSources = append(fetchedSources, srcName)
+
+		if cfg.Digest.DedupCanonicalURLs {
+			for _, article := range articles {
+				models.RecordCanonicalURL(article)
+			}
+		}
+
+		if srcCfg.HasTimeWindow() {
+			var lastRun time.Time
+			var firstSeen func(*models.Article) time.Time
+			if runState != nil {
+				lastRun = runState.LastRun[srcName]
+				firstSeen = func(article *models.Article) time.Time {
+					return runState.FirstSeenAt(articleKey(article), now)
+				}
+			}
+
+			cutoff, err := srcCfg.Cutoff(now, lastRun)
+			if err != nil {
+				log.Printf("Error computing time window for %s: %v", src.Name(), err)
+				continue
+			}
 
-		if len(srcCfg.WordDenylist) > 0 {
 			originalCount := len(articles)
-			articles = source.FilterArticlesByWordDenylist(articles, srcCfg.WordDenylist)
+			articles = source.FilterArticlesByTime(
+				articles,
+				cutoff,
+				srcCfg.UndatedPolicy,
+				firstSeen,
+			)
+			log.Printf(
+				"Kept %d/%d articles from %s after time window filtering",
+				len(articles),
+				originalCount,
+				src.Name(),
+			)
+		}
+
+		if len(srcCfg.WordDenylist) > 0 || len(srcCfg.WordAllowlist) > 0 {
+			originalCount := len(articles)
+			articles = srcWordFilter.FilterArticles(articles)
 			log.Printf(
-				"Kept %d/%d articles from %s after word denylist filtering",
+				"Kept %d/%d articles from %s after word list filtering",
 				len(articles),
 				originalCount,
 				src.Name(),
 			)
 		}
 
+		processed := make([]*models.Article, 0, len(articles))
 		for _, article := range articles {
-			for procI, proc := range srcProcs {
-				if err := proc.Process(article, &srcProcsOpts[procI]); err != nil {
-					log.Printf("Error processing article with %s: %v", proc.Name(), err)
-					continue
-				}
+			err := processor.Run(ctx, article, srcProcs, srcProcsOpts, srcFetcher)
+			var dropErr *processor.DropError
+			if errors.As(err, &dropErr) {
+				log.Printf("Dropping %q after processing error: %v", article.Title, err)
+				dropped = append(dropped, droppedArticle{article: article, err: dropErr})
+				continue
+			}
+			if err != nil {
+				// Interrupted: the remaining articles would only be
+				// half-processed.
+				break
 			}
+			processed = append(processed, article)
+		}
+		articles = processed
+
+		if len(srcCfg.AllowedLanguages) > 0 {
+			originalCount := len(articles)
+			articles = models.FilterArticlesByLanguage(articles, srcCfg.AllowedLanguages)
+			log.Printf(
+				"Kept %d/%d articles from %s after language filtering",
+				len(articles),
+				originalCount,
+				src.Name(),
+			)
+		}
+
+		if srcFilter != nil {
+			originalCount := len(articles)
+			articles = filter.FilterArticles(articles, srcFilter)
+			log.Printf(
+				"Kept %d/%d articles from %s after filtering",
+				len(articles),
+				originalCount,
+				src.Name(),
+			)
+		}
+
+		if srcCfg.MaxArticles > 0 && len(articles) > srcCfg.MaxArticles {
+			log.Printf(
+				"Limiting %s to %d/%d articles",
+				src.Name(),
+				srcCfg.MaxArticles,
+				len(articles),
+			)
+			articles = articles[:srcCfg.MaxArticles]
 		}
 
 		digest.Articles = append(digest.Articles, articles...)
 	}
 
+	// An interrupted run's articles may be missing or half-processed, and
+	// saving its state would skip them on the next run.
+	if err := ctx.Err(); err != nil {
+		log.Fatalf("Interrupted, exiting without writing a digest or saving state: %v", err)
+	}
+
 	if len(digest.Articles) == 0 {
+		reportDropped(dropped)
 		log.Println("No articles found. Exiting.")
 		return
 	}
 
 	log.Printf("Fetched %d articles from all sources", len(digest.Articles))
 
+	for i, proc := range digestProcs {
+		if err := proc.ProcessDigest(digest.Articles, &digestProcsOpts[i]); err != nil {
+			log.Printf("Error processing digest with %s: %v", proc.Name(), err)
+		}
+	}
+
+	if len(cfg.Digest.AllowedLanguages) > 0 {
+		originalCount := len(digest.Articles)
+		digest.Articles = models.FilterArticlesByLanguage(
+			digest.Articles,
+			cfg.Digest.AllowedLanguages,
+		)
+		log.Printf(
+			"Kept %d/%d articles after language filtering",
+			len(digest.Articles),
+			originalCount,
+		)
+	}
+
+	if digestFilter != nil {
+		originalCount := len(digest.Articles)
+		digest.Articles = filter.FilterArticles(digest.Articles, digestFilter)
+		log.Printf(
+			"Kept %d/%d articles after digest filtering",
+			len(digest.Articles),
+			originalCount,
+		)
+	}
+
 	if cfg.Digest.DedupByURL {
 		log.Println("Deduplicating articles by URL")
 		originalCount := len(digest.Articles)
@@ -161,6 +335,33 @@ func main() {
 		)
 	}
 
+	if dedup := cfg.Dedup(); dedup.CanonicalURLs || dedup.Similarity > 0 {
+		log.Println("Deduplicating near-duplicate articles")
+		originalCount := len(digest.Articles)
+		digest.Articles, err = models.DeduplicateArticles(digest.Articles, dedup)
+		if err != nil {
+			log.Fatalf("Error deduplicating articles: %v", err)
+		}
+		log.Printf(
+			"Kept %d/%d articles after near-duplicate detection",
+			len(digest.Articles),
+			originalCount,
+		)
+	}
+
+	if budget := cfg.Budget(); budget.MaxArticles > 0 || budget.MaxWords > 0 {
+		originalCount := len(digest.Articles)
+		digest.Articles, err = models.ApplyBudget(digest.Articles, budget)
+		if err != nil {
+			log.Fatalf("Error applying digest budget: %v", err)
+		}
+		log.Printf(
+			"Kept %d/%d articles after applying digest budget",
+			len(digest.Articles),
+			originalCount,
+		)
+	}
+
 	if len(cfg.Digest.SortBy) > 0 {
 		log.Println("Sorting articles by configured properties: ", cfg.Digest.SortBy)
 
@@ -170,6 +371,17 @@ func main() {
 		}
 	}
 
+	if cfg.Digest.GroupBy != "" {
+		if err := digest.GroupBy(cfg.Digest.GroupBy); err != nil {
+			log.Fatalf("Error grouping articles: %v", err)
+		}
+		log.Printf(
+			"Grouped articles into %d sections by %s",
+			len(digest.Sections),
+			cfg.Digest.GroupBy,
+		)
+	}
+
 	var finalOutputPath string
 
 	formattedPath, err := executeTmpl(cfg.Digest.OutputPath, now)
@@ -180,9 +392,7 @@ func main() {
 		finalOutputPath = formattedPath
 	}
 
-	if !filepath.IsAbs(finalOutputPath) && *outputDir != "" {
-		finalOutputPath = filepath.Join(*outputDir, finalOutputPath)
-	}
+	finalOutputPath = resolvePath(finalOutputPath, *outputDir)
 
 	oDir := filepath.Dir(finalOutputPath)
 	if err := os.MkdirAll(oDir, 0o755); err != nil {
@@ -200,9 +410,66 @@ func main() {
 		log.Fatalf("Error formatting digest: %v", err)
 	}
 
+	if runState != nil {
+		for _, srcName := range fetchedSources {
+			runState.LastRun[srcName] = now
+		}
+		runState.Prune(now)
+
+		if err := runState.SaveFile(statePath); err != nil {
+			log.Fatalf("Error saving state: %v", err)
+		}
+		log.Printf("Saved state to %s", statePath)
+	}
+
+	reportDropped(dropped)
 	log.Println("Dijester completed successfully")
 }
 
+// droppedArticle is an article dropped because a processor failed.
+type droppedArticle struct {
+	article *models.Article
+	err     *processor.DropError
+}
+
+// reportDropped logs the articles that were dropped because of processing
+// errors, so they can be read elsewhere or their processing fixed.
+func reportDropped(dropped []droppedArticle) {
+	if len(dropped) == 0 {
+		return
+	}
+
+	log.Printf("Dropped %d articles after processing errors:", len(dropped))
+	for _, d := range dropped {
+		log.Printf(
+			"  - %q (%s) from %s: %v",
+			d.article.Title,
+			d.article.URL,
+			d.article.SourceName,
+			d.err,
+		)
+	}
+}
+
+// resolvePath resolves a relative path against the output directory, if one
+// was given.
+func resolvePath(path, outputDir string) string {
+	if !filepath.IsAbs(path) && outputDir != "" {
+		return filepath.Join(outputDir, path)
+	}
+
+	return path
+}
+
+// articleKey returns the key used to identify an article across runs.
+func articleKey(article *models.Article) string {
+	if article.URL != "" {
+		return article.URL
+	}
+
+	return article.SourceName + "\x00" + article.Title
+}
+
 type templateData struct {
 	Now      time.Time
 	Year     int
@@ -1,12 +1,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/BurntSushi/toml"
 
 	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/filter"
+	"github.com/shrik450/dijester/pkg/images"
+	"github.com/shrik450/dijester/pkg/models"
 	"github.com/shrik450/dijester/pkg/processor"
 	"github.com/shrik450/dijester/pkg/source"
 )
@@ -27,8 +31,44 @@ type Config struct {
 		// DedupByURL determines whether to deduplicate articles by URL
 		DedupByURL bool `toml:"dedup_by_url"`
 
+		// DedupCanonicalURLs determines whether to deduplicate articles by
+		// canonical URL, ignoring tracking parameters and trivial differences
+		DedupCanonicalURLs bool `toml:"dedup_canonical_urls"`
+
+		// DedupSimilarity is the content similarity (0 to 1) above which
+		// articles are considered duplicates. 0 disables this check.
+		DedupSimilarity float64 `toml:"dedup_similarity"`
+
 		// SortBy contains a list of article properties to sort by
 		SortBy []string `toml:"sort_by"`
+
+		// GroupBy splits the digest into sections by an article property:
+		// "SourceName", "Tags" or "Domain"
+		GroupBy string `toml:"group_by"`
+
+		// Filter is an expression articles from all sources must match to be
+		// included in the digest
+		Filter string `toml:"filter"`
+
+		// AllowedLanguages drops articles detected to be in other languages,
+		// e.g. ["en", "de"]
+		AllowedLanguages []string `toml:"allowed_languages"`
+
+		// MaxArticles limits the number of articles in the digest (0 means no
+		// limit)
+		MaxArticles int `toml:"max_articles"`
+
+		// MaxWords limits the total number of words in the digest (0 means no
+		// limit)
+		MaxWords int `toml:"max_words"`
+
+		// BudgetStrategy decides which articles are kept when MaxArticles or
+		// MaxWords is exceeded: "round_robin", "proportional" or "by_score"
+		BudgetStrategy string `toml:"budget_strategy"`
+
+		// StatePath is where state that persists between runs, such as the
+		// time of the last successful run, is stored
+		StatePath string `toml:"state_path"`
 	} `toml:"digest"`
 
 	// Sources is a map of source configurations
@@ -64,5 +104,86 @@ func Load(data string) (*Config, error) {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
 	return config, nil
 }
+
+// Validate checks the configuration for invalid or inconsistent values.
+func (c *Config) Validate() error {
+	if err := c.Budget().Validate(); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+
+	if err := c.Dedup().Validate(); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+
+	if _, err := filter.Compile(c.Digest.Filter); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+
+	if err := models.ValidateGroupBy(c.Digest.GroupBy); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+
+	if err := models.ValidateLanguages(c.Digest.AllowedLanguages); err != nil {
+		return fmt.Errorf("digest: allowed_languages: %w", err)
+	}
+
+	if err := c.ProcessorConfig.Validate(); err != nil {
+		return fmt.Errorf("global_processors: %w", err)
+	}
+
+	if imagesConfig, ok := c.Formatting["images"]; ok {
+		imagesMap, ok := imagesConfig.(map[string]any)
+		if !ok {
+			return errors.New("formatting: images must be a table")
+		}
+		if _, err := images.OptionsFromConfig(imagesMap); err != nil {
+			return fmt.Errorf("formatting: images: %w", err)
+		}
+	}
+
+	for name, srcCfg := range c.Sources {
+		if err := srcCfg.Validate(); err != nil {
+			return fmt.Errorf("source %s: %w", name, err)
+		}
+
+		if srcCfg.ProcessorConfig != nil {
+			if err := srcCfg.ProcessorConfig.Inherit(c.ProcessorConfig).Validate(); err != nil {
+				return fmt.Errorf("source %s: processor_config: %w", name, err)
+			}
+		}
+
+		if srcCfg.NeedsState() && c.Digest.StatePath == "" {
+			return fmt.Errorf(
+				"source %s: digest.state_path must be set to use since = %q or undated_policy = %q",
+				name,
+				source.SinceLastRun,
+				source.UndatedFirstSeen,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Dedup returns the options for near-duplicate detection.
+func (c *Config) Dedup() models.DedupOptions {
+	return models.DedupOptions{
+		CanonicalURLs: c.Digest.DedupCanonicalURLs,
+		Similarity:    c.Digest.DedupSimilarity,
+	}
+}
+
+// Budget returns the digest-level article budget.
+func (c *Config) Budget() models.Budget {
+	return models.Budget{
+		MaxArticles: c.Digest.MaxArticles,
+		MaxWords:    c.Digest.MaxWords,
+		Strategy:    c.Digest.BudgetStrategy,
+	}
+}
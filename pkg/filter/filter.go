@@ -0,0 +1,408 @@
+package filter
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// Filter is a compiled filter expression. Articles for which the expression
+// evaluates to true are kept.
+type Filter struct {
+	source string
+	root   node
+}
+
+// Compile parses a filter expression. An empty expression compiles to nil,
+// which keeps every article.
+func Compile(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	root, err := parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+
+	return &Filter{source: expr, root: root}, nil
+}
+
+// String returns the source expression of the filter.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.source
+}
+
+// Match reports whether the article satisfies the filter. A nil filter
+// matches every article.
+func (f *Filter) Match(article *models.Article) bool {
+	if f == nil {
+		return true
+	}
+
+	return truthy(f.root.eval(&env{article: article, now: time.Now()}))
+}
+
+// FilterArticles returns the articles that match the filter, logging the ones
+// that were filtered out.
+func FilterArticles(articles []*models.Article, f *Filter) []*models.Article {
+	if f == nil || len(articles) == 0 {
+		return articles
+	}
+
+	filtered := make([]*models.Article, 0, len(articles))
+	for _, article := range articles {
+		if !f.Match(article) {
+			log.Printf("Filtered out %q: does not match filter %q", article.Title, f.source)
+			continue
+		}
+		filtered = append(filtered, article)
+	}
+
+	return filtered
+}
+
+// env is the environment a filter expression is evaluated in.
+type env struct {
+	article *models.Article
+	now     time.Time
+
+	text    *string
+	wordCnt *int
+}
+
+func (e *env) plainText() string {
+	if e.text == nil {
+		text := models.PlainText(e.article.Content)
+		e.text = &text
+	}
+	return *e.text
+}
+
+// wordCount returns the word count recorded by the stats processor, or
+// counts the words in the article's text if there is none.
+func (e *env) wordCount() int {
+	if e.wordCnt == nil {
+		count, ok := e.article.Metadata[models.MetadataWordCount].(int)
+		if !ok {
+			count = models.CountWords(e.plainText())
+		}
+		e.wordCnt = &count
+	}
+	return *e.wordCnt
+}
+
+// metadataPrefix is the prefix of identifiers that explicitly refer to a
+// metadata key, e.g. "Metadata.score", spelled like metadata sort fields.
+const metadataPrefix = "Metadata."
+
+// fields are the article fields expressions can refer to by name.
+var fields = []string{
+	"title", "author", "url", "summary", "content", "text", "source", "tags",
+	"word_count", "reading_time", "image_count", "domain", "age_hours",
+}
+
+// checkIdent checks an identifier at pos. Identifiers other than fields are
+// metadata keys, except for ones that look like a misspelled field, as those
+// would otherwise silently be nil. Such keys can be written as
+// "Metadata.key".
+func checkIdent(name string, pos int) error {
+	if slices.Contains(fields, name) {
+		return nil
+	}
+	if key, ok := strings.CutPrefix(name, metadataPrefix); ok {
+		if key == "" {
+			return fmt.Errorf("missing metadata key at position %d", pos)
+		}
+		return nil
+	}
+
+	for _, field := range fields {
+		if strings.EqualFold(name, field) || editDistance(name, field) <= 1 {
+			return fmt.Errorf(
+				"unknown field %q at position %d, did you mean %q? Write %s%s for a metadata key",
+				name,
+				pos,
+				field,
+				metadataPrefix,
+				name,
+			)
+		}
+	}
+	return nil
+}
+
+// editDistance returns the number of single character insertions,
+// deletions, substitutions and transpositions of adjacent characters needed
+// to turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(ra)][len(rb)]
+}
+
+// lookup resolves an identifier checked by checkIdent to a value: an
+// article field, or a metadata key, written as it is or as "Metadata.key".
+// Missing metadata resolves to nil.
+func (e *env) lookup(name string) any {
+	a := e.article
+
+	switch name {
+	case "title":
+		return a.Title
+	case "author":
+		return a.Author
+	case "url":
+		return a.URL
+	case "summary":
+		return a.Summary
+	case "content":
+		return a.Content
+	case "text":
+		return e.plainText()
+	case "source":
+		return a.SourceName
+	case "tags":
+		tags := make([]any, len(a.Tags))
+		for i, tag := range a.Tags {
+			tags[i] = tag
+		}
+		return tags
+	case "word_count":
+		return float64(e.wordCount())
+	case "reading_time":
+		if models.HasReadingTime(a) {
+			return float64(models.ReadingTime(a))
+		}
+		return float64(models.ReadingMinutes(e.wordCount(), models.DefaultReadingWPM))
+	case "image_count":
+		if count, ok := a.Metadata[models.MetadataImageCount].(int); ok {
+			return float64(count)
+		}
+		return float64(models.CountImages(a.Content))
+	case "domain":
+		return models.Domain(a.URL)
+	case "age_hours":
+		if a.PublishedAt.IsZero() {
+			return nil
+		}
+		return e.now.Sub(a.PublishedAt).Hours()
+	}
+
+	key, _ := strings.CutPrefix(name, metadataPrefix)
+	if v, ok := a.Metadata[key]; ok {
+		return normalize(v)
+	}
+
+	return nil
+}
+
+// normalize converts metadata values to the small set of types expressions
+// operate on: string, float64, bool, []any and nil.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool, float64:
+		return v
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalize(item)
+		}
+		return items
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = normalize(rv.Index(i).Interface())
+		}
+		return items
+	}
+
+	return fmt.Sprint(v)
+}
+
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case []any:
+		return len(v) > 0
+	}
+	return false
+}
+
+func (n *literalNode) eval(*env) any {
+	return n.value
+}
+
+func (n *identNode) eval(e *env) any {
+	return e.lookup(n.name)
+}
+
+func (n *listNode) eval(e *env) any {
+	items := make([]any, len(n.items))
+	for i, item := range n.items {
+		items[i] = item.eval(e)
+	}
+	return items
+}
+
+func (n *notNode) eval(e *env) any {
+	return !truthy(n.operand.eval(e))
+}
+
+func (n *logicalNode) eval(e *env) any {
+	left := truthy(n.left.eval(e))
+	if n.op == "&&" {
+		return left && truthy(n.right.eval(e))
+	}
+	return left || truthy(n.right.eval(e))
+}
+
+func (n *matchNode) eval(e *env) any {
+	s, ok := n.operand.eval(e).(string)
+	if !ok {
+		return n.negate
+	}
+	return n.re.MatchString(s) != n.negate
+}
+
+func (n *compareNode) eval(e *env) any {
+	left, right := n.left.eval(e), n.right.eval(e)
+
+	switch n.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	case "in":
+		return contains(right, left)
+	case "contains":
+		return contains(left, right)
+	}
+
+	c, ok := order(left, right)
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+
+	return false
+}
+
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	case float64:
+		b, ok := b.(float64)
+		return ok && a == b
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// contains reports whether haystack contains needle. Lists contain their
+// elements; strings contain their substrings.
+func contains(haystack, needle any) bool {
+	switch h := haystack.(type) {
+	case []any:
+		for _, item := range h {
+			if equal(item, needle) {
+				return true
+			}
+		}
+	case string:
+		n, ok := needle.(string)
+		return ok && strings.Contains(h, n)
+	}
+	return false
+}
+
+// order compares two values of the same orderable type.
+func order(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		return cmp.Compare(a, b), true
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a, b), true
+	}
+	return 0, false
+}
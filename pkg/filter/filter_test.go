@@ -0,0 +1,150 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func testArticle() *models.Article {
+	return &models.Article{
+		Title:       "Go 1.24 Released",
+		Author:      "Gopher",
+		URL:         "https://www.go.dev/blog/go1.24",
+		Content:     `<p>The <a href="https://example.com/said">latest</a> Go release is here.</p>`,
+		Summary:     "A new release",
+		SourceName:  "hackernews",
+		PublishedAt: time.Now().Add(-2 * time.Hour),
+		Tags:        []string{"go", "release"},
+		Metadata: map[string]any{
+			"score":    250,
+			"comments": int64(42),
+			"ratio":    0.5,
+			"dead":     false,
+			"tag":      "featured",
+		},
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: `score >= 200`, want: true},
+		{expr: `score > 250`, want: false},
+		{expr: `Metadata.score >= 200`, want: true},
+		{expr: `comments == 42`, want: true},
+		{expr: `ratio < 1`, want: true},
+		{expr: `!dead`, want: true},
+		{expr: `Metadata.tag == "featured"`, want: true},
+		{expr: `title =~ "(?i)go"`, want: true},
+		{expr: `title !~ "(?i)crypto"`, want: true},
+		{expr: `!(title =~ "(?i)crypto")`, want: true},
+		{expr: `"go" in tags`, want: true},
+		{expr: `"rust" in tags`, want: false},
+		{expr: `tags contains "release"`, want: true},
+		{expr: `source in ["hackernews", "lobsters"]`, want: true},
+		{expr: `"latest" in text`, want: true},
+		{expr: `text contains "said"`, want: false},
+		{expr: `content contains "said"`, want: true},
+		{expr: `word_count > 3 && word_count < 10`, want: true},
+		{expr: `reading_time == 1`, want: true},
+		{expr: `image_count == 0`, want: true},
+		{expr: `domain == "go.dev"`, want: true},
+		{expr: `age_hours < 24`, want: true},
+		{expr: `author == 'Gopher' and not (summary == "")`, want: true},
+		{expr: `missing == null`, want: true},
+		{expr: `Metadata.missing > 5`, want: false},
+		{expr: `score > "abc"`, want: false},
+		{expr: `score < 100 || "go" in tags`, want: true},
+		{
+			expr: `score >= 200 && !(title =~ "(?i)crypto") && word_count > 3 && ` +
+				`"go" in tags`,
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile() returned error: %v", err)
+			}
+
+			if got := f.Match(testArticle()); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: `score >=`, wantErr: "unexpected end of expression"},
+		{expr: `(score > 1`, wantErr: `expected ")"`},
+		{expr: `title =~ "("`, wantErr: "invalid regular expression"},
+		{expr: `title =~ other`, wantErr: "expected a string regular expression"},
+		{expr: `title == "unterminated`, wantErr: "unterminated string"},
+		{expr: `word_count > 1 title`, wantErr: "unexpected \"title\" at position 15"},
+		{expr: `score # 1`, wantErr: "unexpected character"},
+		{expr: `source in ["a" "b"]`, wantErr: `expected "," or "]"`},
+		{expr: `titel contains "go"`, wantErr: `unknown field "titel" at position 0`},
+		{expr: `word_cont > 100`, wantErr: `unknown field "word_cont"`},
+		{
+			expr:    `tag == "featured"`,
+			wantErr: `did you mean "tags"? Write Metadata.tag for a metadata key`,
+		},
+		{
+			expr:    `Title contains "go"`,
+			wantErr: `unknown field "Title" at position 0, did you mean "title"?`,
+		},
+		{expr: `Metadata. == 1`, wantErr: "missing metadata key at position 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if err == nil {
+				t.Fatal("Compile() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompile_Empty(t *testing.T) {
+	f, err := Compile("  ")
+	if err != nil {
+		t.Fatalf("Compile() returned error: %v", err)
+	}
+	if f != nil {
+		t.Errorf("Compile() of an empty expression should return nil, got %v", f)
+	}
+	if !f.Match(testArticle()) {
+		t.Error("A nil filter should match every article")
+	}
+}
+
+func TestFilterArticles(t *testing.T) {
+	low := testArticle()
+	low.Metadata["score"] = 10
+	high := testArticle()
+
+	f, err := Compile(`score >= 200`)
+	if err != nil {
+		t.Fatalf("Compile() returned error: %v", err)
+	}
+
+	got := FilterArticles([]*models.Article{low, high}, f)
+	if len(got) != 1 || got[0] != high {
+		t.Errorf("FilterArticles() = %v, want only the high scoring article", got)
+	}
+}
@@ -0,0 +1,264 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// node is a node of a parsed filter expression.
+type node interface {
+	eval(env *env) any
+}
+
+type literalNode struct {
+	value any
+}
+
+type identNode struct {
+	name string
+}
+
+type listNode struct {
+	items []node
+}
+
+type notNode struct {
+	operand node
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+type matchNode struct {
+	operand node
+	re      *regexp.Regexp
+	negate  bool
+}
+
+// parser is a recursive descent parser for filter expressions. The grammar,
+// from lowest to highest precedence, is:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | comparison
+//	comparison = operand [ op operand ]
+//	operand    = string | number | ident | list | "(" or ")"
+//	list       = "[" [ operand { "," operand } ] "]"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(input string) (node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isOperator("==", "!=", "<", "<=", ">", ">=", "=~", "!~", "in", "contains") {
+		return left, nil
+	}
+
+	opTok := p.next()
+
+	if opTok.text == "=~" || opTok.text == "!~" {
+		patternTok := p.next()
+		if patternTok.kind != tokenString {
+			return nil, fmt.Errorf(
+				"expected a string regular expression after %s at position %d, got %s",
+				opTok.text,
+				patternTok.pos,
+				patternTok,
+			)
+		}
+
+		re, err := regexp.Compile(patternTok.text)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid regular expression at position %d: %w",
+				patternTok.pos,
+				err,
+			)
+		}
+
+		return &matchNode{operand: left, re: re, negate: opTok.text == "!~"}, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return &compareNode{op: opTok.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+
+	case tokenNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", tok, tok.pos)
+		}
+		return &literalNode{value: n}, nil
+
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		}
+		if err := checkIdent(tok.text, tok.pos); err != nil {
+			return nil, err
+		}
+		return &identNode{name: tok.text}, nil
+
+	case tokenLBracket:
+		list := &listNode{}
+		if p.peek().kind == tokenRBracket {
+			p.next()
+			return list, nil
+		}
+
+		for {
+			item, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+
+			sep := p.next()
+			if sep.kind == tokenRBracket {
+				return list, nil
+			}
+			if sep.kind != tokenComma {
+				return nil, fmt.Errorf("expected \",\" or \"]\" at position %d, got %s", sep.pos, sep)
+			}
+		}
+
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		closing := p.next()
+		if closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at position %d, got %s", closing.pos, closing)
+		}
+		return inner, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+}
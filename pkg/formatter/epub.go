@@ -1,7 +1,9 @@
 package formatter
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -9,6 +11,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +21,7 @@ import (
 
 	"github.com/shrik450/dijester/pkg/constants"
 	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/images"
 	"github.com/shrik450/dijester/pkg/models"
 )
 
@@ -56,6 +61,9 @@ func (f *EPUBFormatter) Format(w io.Writer, digest *models.Digest, opts *Options
 	e.SetDescription(
 		fmt.Sprintf("Digest generated on %s", digest.GeneratedAt.Format(time.RFC1123)),
 	)
+	if lang := digestLanguage(digest); lang != "" {
+		e.SetLang(lang)
+	}
 
 	tocHTML := f.generateTOC(digest)
 	_, err = e.AddSection(tocHTML, "Table of Contents", "", "")
@@ -69,12 +77,43 @@ func (f *EPUBFormatter) Format(w io.Writer, digest *models.Digest, opts *Options
 	}
 	defer os.RemoveAll(tmpDir)
 
-	for i, article := range digest.Articles {
-		sectionHTML := f.generateArticleHTML(e, article, opts, tmpDir, fetcher)
+	if len(digest.Sections) == 0 {
+		for i, article := range digest.Articles {
+			sectionHTML := f.generateArticleHTML(e, article, opts, tmpDir, fetcher)
 
-		_, err = e.AddSection(sectionHTML, article.Title, fmt.Sprintf("article-%d", i+1), "")
-		if err != nil {
-			return fmt.Errorf("error adding article %d: %w", i+1, err)
+			_, err = e.AddSection(sectionHTML, article.Title, articleFilename(i+1), "")
+			if err != nil {
+				return fmt.Errorf("error adding article %d: %w", i+1, err)
+			}
+		}
+	} else {
+		articleNum := 0
+		for i, section := range digest.Sections {
+			sectionFile, err := e.AddSection(
+				f.generateSectionHTML(section, articleNum+1),
+				sectionTitle(section),
+				sectionFilename(i+1),
+				"",
+			)
+			if err != nil {
+				return fmt.Errorf("error adding section %d: %w", i+1, err)
+			}
+
+			for _, article := range section.Articles {
+				articleNum++
+				articleHTML := f.generateArticleHTML(e, article, opts, tmpDir, fetcher)
+
+				_, err = e.AddSubSection(
+					sectionFile,
+					articleHTML,
+					article.Title,
+					articleFilename(articleNum),
+					"",
+				)
+				if err != nil {
+					return fmt.Errorf("error adding article %d: %w", articleNum, err)
+				}
+			}
 		}
 	}
 
@@ -95,36 +134,137 @@ var tocTemplate = `
 	<h1>{{.Title}}</h1>
 	<p>Generated on: {{.GeneratedAt}}</p>
 	<h2>Contents</h2>
-	<ol>
-		{{range $i, $a := .Articles}}
-			<li><a href="article-{{$i}}.xhtml">{{$a.Title}}</a></li>
-		{{end}}
-	</ol>
+	{{if .Sections}}
+		<ol>
+			{{range .Sections}}
+				<li>
+					<a href="{{.File}}">{{.Title}}</a> ({{len .Articles}})
+					<ol>
+						{{range .Articles}}
+							<li>
+								<a href="{{.File}}">{{.Title}}</a>
+								{{if .ReadingTime}}· {{.ReadingTime}}{{end}}
+							</li>
+						{{end}}
+					</ol>
+				</li>
+			{{end}}
+		</ol>
+	{{else}}
+		<ol>
+			{{range .Articles}}
+				<li>
+					<a href="{{.File}}">{{.Title}}</a>
+					{{if .ReadingTime}}· {{.ReadingTime}}{{end}}
+				</li>
+			{{end}}
+		</ol>
+	{{end}}
 </body>
 </html>
 `
 
+// tocEntry is a link in the table of contents.
+type tocEntry struct {
+	Title       string
+	File        string
+	ReadingTime string
+	Articles    []tocEntry
+}
+
 // generateTOC creates the HTML table of contents.
 func (f *EPUBFormatter) generateTOC(digest *models.Digest) string {
 	tmpl := template.Must(template.New("toc").Parse(tocTemplate))
 	var sb strings.Builder
 
+	articles := make([]tocEntry, len(digest.Articles))
+	for i, article := range digest.Articles {
+		articles[i] = tocEntry{
+			Title:       article.Title,
+			File:        articleFilename(i+1) + ".xhtml",
+			ReadingTime: readingTime(article),
+		}
+	}
+
+	sections := make([]tocEntry, len(digest.Sections))
+	articleNum := 0
+	for i, section := range digest.Sections {
+		entry := tocEntry{Title: section.Title, File: sectionFilename(i+1) + ".xhtml"}
+		for _, article := range section.Articles {
+			articleNum++
+			entry.Articles = append(entry.Articles, tocEntry{
+				Title:       article.Title,
+				File:        articleFilename(articleNum) + ".xhtml",
+				ReadingTime: readingTime(article),
+			})
+		}
+		sections[i] = entry
+	}
+
 	tmpl.Execute(&sb, map[string]any{
 		"Title":       digest.Title,
 		"GeneratedAt": digest.GeneratedAt.Format(time.RFC1123),
-		"Articles":    digest.Articles,
+		"Articles":    articles,
+		"Sections":    sections,
 	})
 
 	return sb.String()
 }
 
+var sectionTemplate = `
+<h1>{{.Title}}</h1>
+<p>{{.Count}} {{if eq .Count 1}}article{{else}}articles{{end}}</p>
+<ol start="{{.Start}}">
+	{{range .Articles}}
+		<li><a href="{{.File}}">{{.Title}}</a></li>
+	{{end}}
+</ol>
+`
+
+// generateSectionHTML creates the HTML for a section's title page. start is
+// the number of the section's first article in the digest.
+func (f *EPUBFormatter) generateSectionHTML(section *models.Section, start int) string {
+	tmpl := template.Must(template.New("section").Parse(sectionTemplate))
+	var sb strings.Builder
+
+	articles := make([]tocEntry, len(section.Articles))
+	for i, article := range section.Articles {
+		articles[i] = tocEntry{Title: article.Title, File: articleFilename(start+i) + ".xhtml"}
+	}
+
+	tmpl.Execute(&sb, map[string]any{
+		"Title":    section.Title,
+		"Count":    len(section.Articles),
+		"Start":    start,
+		"Articles": articles,
+	})
+
+	return sb.String()
+}
+
+// sectionTitle returns the title of a section as shown in the EPUB's
+// navigation, including its article count.
+func sectionTitle(section *models.Section) string {
+	return fmt.Sprintf("%s (%d)", section.Title, len(section.Articles))
+}
+
+func sectionFilename(n int) string {
+	return fmt.Sprintf("section-%d", n)
+}
+
+func articleFilename(n int) string {
+	return fmt.Sprintf("article-%d", n)
+}
+
 var articleTemplate = `
+{{if .Language}}<div lang="{{.Language}}" xml:lang="{{.Language}}">{{end}}
 <h1>{{.Title}}</h1>
 
 <div style="background-color: #f5f5f5; border: 1px solid #e0e0e0; padding: 10px; margin-bottom: 20px; font-size: 0.9em; color: #555; border-radius: 4px;">
 	<div style="margin-bottom: 5px;">
 		{{if .Author}}<span style="margin-right: 15px;">By {{.Author}}</span>{{end}}
 		{{if .PublishedAt}}<span style="margin-right: 15px;">at {{.PublishedAt}}</span>{{end}}
+		{{if .ReadingTime}}<span style="margin-right: 15px;">{{.ReadingTime}}</span>{{end}}
 	</div>
 	<div>
 		<span style="margin-right: 15px;"><a href="{{.URL}}" style="color: #1a73e8; text-decoration: none;">Link</a></span>
@@ -157,6 +297,7 @@ var articleTemplate = `
 		</div>
 	{{end}}
 {{end}}
+{{if .Language}}</div>{{end}}
 `
 
 // generateArticleHTML creates the HTML for a single article.
@@ -170,7 +311,7 @@ func (f *EPUBFormatter) generateArticleHTML(
 	tmpl := template.Must(template.New("article").Parse(articleTemplate))
 
 	if opts.StoreImages {
-		embedImages(e, article, tmpDir, fetcher)
+		embedImages(e, article, tmpDir, fetcher, opts.Images)
 	}
 
 	var sb strings.Builder
@@ -178,7 +319,9 @@ func (f *EPUBFormatter) generateArticleHTML(
 		"Title":           article.Title,
 		"Content":         template.HTML(article.Content),
 		"Author":          article.Author,
-		"PublishedAt":     article.PublishedAt.Format(time.RFC1123),
+		"PublishedAt":     formatPublishedAt(article.PublishedAt),
+		"ReadingTime":     readingTime(article),
+		"Language":        models.Language(article),
 		"URL":             article.URL,
 		"SourceName":      article.SourceName,
 		"Tags":            strings.Join(article.Tags, ", "),
@@ -191,7 +334,52 @@ func (f *EPUBFormatter) generateArticleHTML(
 	return sb.String()
 }
 
-func embedImages(e *epub.Epub, article *models.Article, tmpDir string, fetcher fetcher.Fetcher) {
+// formatPublishedAt formats a publication date for display, returning an empty
+// string for articles without one.
+func formatPublishedAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC1123)
+}
+
+// digestLanguage returns the most common language among the digest's
+// articles, or an empty string if no article's language is known.
+func digestLanguage(digest *models.Digest) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, article := range digest.Articles {
+		lang := models.Language(article)
+		if lang == "" {
+			continue
+		}
+		counts[lang]++
+		if counts[lang] > counts[best] {
+			best = lang
+		}
+	}
+
+	return best
+}
+
+// readingTime formats an article's reading time for display, returning an
+// empty string for articles without one.
+func readingTime(article *models.Article) string {
+	if !models.HasReadingTime(article) {
+		return ""
+	}
+
+	return models.FormatReadingTime(models.ReadingTime(article))
+}
+
+func embedImages(
+	e *epub.Epub,
+	article *models.Article,
+	tmpDir string,
+	fetcher fetcher.Fetcher,
+	imageOpts *images.Options,
+) {
 	node, err := html.Parse(strings.NewReader(article.Content))
 	if err != nil {
 		log.Printf("error parsing HTML: %s", err)
@@ -205,53 +393,13 @@ func embedImages(e *epub.Epub, article *models.Article, tmpDir string, fetcher f
 
 	ctx := context.Background()
 
+	// Images are collected first, as embedding them may remove them from the
+	// tree.
+	var imgs []*html.Node
 	var traverse func(*html.Node)
 	traverse = func(n *html.Node) {
 		if n.Type == html.ElementNode && n.Data == "img" {
-			for i := range n.Attr {
-				attr := &n.Attr[i]
-				if attr.Key == "src" {
-					beforeURL := attr.Val
-					if strings.HasPrefix(beforeURL, "data:image/") {
-						continue
-					}
-
-					resolvedURL, err := resolveURL(articleUrl, attr.Val)
-					if err != nil {
-						log.Printf("error resolving URL: %s", err)
-						continue
-					}
-
-					tmpFile, err := os.CreateTemp(tmpDir, "img-")
-					if err != nil {
-						log.Printf("error creating temp file: %s", err)
-						continue
-					}
-					defer tmpFile.Close()
-					// tmpFile shouldn't be deleted here, as the epub only
-					// "grabs" the file when it writes the full epub. It will
-					// be deleted when the entire `tmpDir` (managed by the
-					// calling function) is deleted.
-
-					err = fetcher.StreamURL(ctx, resolvedURL, tmpFile)
-					if err != nil {
-						log.Printf("error fetching image: %s", err)
-						continue
-					}
-
-					newURL, err := e.AddImage(tmpFile.Name(), "")
-					if err != nil {
-						log.Printf("error adding image to EPUB: %s", err)
-						continue
-					}
-
-					if newURL != "" {
-						attr.Val = newURL
-					}
-
-					break
-				}
-			}
+			imgs = append(imgs, n)
 		}
 
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -261,6 +409,10 @@ func embedImages(e *epub.Epub, article *models.Article, tmpDir string, fetcher f
 
 	traverse(node)
 
+	for _, img := range imgs {
+		embedImage(ctx, e, img, articleUrl, tmpDir, fetcher, imageOpts)
+	}
+
 	var buf strings.Builder
 	if err := html.Render(&buf, node); err != nil {
 		log.Printf("error rendering HTML: %s", err)
@@ -274,6 +426,139 @@ func embedImages(e *epub.Epub, article *models.Article, tmpDir string, fetcher f
 	article.Content = newContent
 }
 
+// embedImage adds the image an <img> element points to to the EPUB, and
+// points the element at the embedded copy. If imageOpts is set, the image is
+// optimized first; tracking pixels and tiny images are removed, and images in
+// formats that can't be converted are replaced by their alt text.
+func embedImage(
+	ctx context.Context,
+	e *epub.Epub,
+	img *html.Node,
+	articleUrl *url.URL,
+	tmpDir string,
+	fetcher fetcher.Fetcher,
+	imageOpts *images.Options,
+) {
+	src := attrValue(img, "src")
+	if src == "" || strings.HasPrefix(src, "data:image/") {
+		return
+	}
+
+	if imageOpts != nil {
+		width, _ := strconv.Atoi(attrValue(img, "width"))
+		height, _ := strconv.Atoi(attrValue(img, "height"))
+		if imageOpts.IsTooSmall(width, height) {
+			removeImage(img, false)
+			return
+		}
+	}
+
+	resolvedURL, err := resolveURL(articleUrl, src)
+	if err != nil {
+		log.Printf("error resolving URL: %s", err)
+		return
+	}
+
+	tmpFile, err := os.CreateTemp(tmpDir, "img-")
+	if err != nil {
+		log.Printf("error creating temp file: %s", err)
+		return
+	}
+	defer tmpFile.Close()
+	// tmpFile shouldn't be deleted here, as the epub only "grabs" the file
+	// when it writes the full epub. It will be deleted when the entire
+	// `tmpDir` (managed by the calling function) is deleted.
+
+	if imageOpts == nil {
+		err = fetcher.StreamURL(ctx, resolvedURL, tmpFile)
+		if err != nil {
+			log.Printf("error fetching image: %s", err)
+			return
+		}
+	} else {
+		var original bytes.Buffer
+		err = fetcher.StreamURL(ctx, resolvedURL, imageOpts.LimitWriter(&original))
+		if errors.Is(err, images.ErrTooLarge) {
+			log.Printf("dropping image %s: %s", resolvedURL, err)
+			removeImage(img, true)
+			return
+		}
+		if err != nil {
+			log.Printf("error fetching image: %s", err)
+			return
+		}
+
+		data, err := images.Optimize(original.Bytes(), *imageOpts)
+		switch {
+		case errors.Is(err, images.ErrTooSmall):
+			removeImage(img, false)
+			return
+		case errors.Is(err, images.ErrUnsupportedFormat), errors.Is(err, images.ErrTooLarge):
+			log.Printf("dropping image %s: %s", resolvedURL, err)
+			removeImage(img, true)
+			return
+		case err != nil:
+			log.Printf("error optimizing image %s: %s; using original", resolvedURL, err)
+			data = original.Bytes()
+		}
+
+		if _, err := tmpFile.Write(data); err != nil {
+			log.Printf("error writing image: %s", err)
+			return
+		}
+	}
+
+	newURL, err := e.AddImage(tmpFile.Name(), "")
+	if err != nil {
+		log.Printf("error adding image to EPUB: %s", err)
+		return
+	}
+
+	if newURL != "" {
+		setAttr(img, "src", newURL)
+		// Other candidates would point at the original, unoptimized images.
+		removeAttr(img, "srcset")
+	}
+}
+
+// removeImage removes an <img> element, replacing it with its alt text if
+// keepAlt is set and it has any.
+func removeImage(img *html.Node, keepAlt bool) {
+	if img.Parent == nil {
+		return
+	}
+
+	if alt := strings.TrimSpace(attrValue(img, "alt")); keepAlt && alt != "" {
+		img.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: "[" + alt + "]"}, img)
+	}
+	img.Parent.RemoveChild(img)
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeAttr(n *html.Node, key string) {
+	n.Attr = slices.DeleteFunc(n.Attr, func(attr html.Attribute) bool {
+		return attr.Key == key
+	})
+}
+
 // resolveURL resolves a potentially relative URL against a base URL.
 func resolveURL(baseURL *url.URL, urlStr string) (string, error) {
 	if strings.HasPrefix(urlStr, "http://") || strings.HasPrefix(urlStr, "https://") {
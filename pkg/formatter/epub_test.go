@@ -3,6 +3,9 @@ package formatter
 import (
 	"archive/zip"
 	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +15,7 @@ import (
 	"time"
 
 	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/images"
 	"github.com/shrik450/dijester/pkg/models"
 )
 
@@ -74,6 +78,113 @@ func TestEPUBFormatter_Format(t *testing.T) {
 	}
 }
 
+func TestEPUBFormatter_Format_Sections(t *testing.T) {
+	first := &models.Article{Title: "Article 1", Content: "<p>One</p>"}
+	second := &models.Article{Title: "Article 2", Content: "<p>Two</p>"}
+	digest := &models.Digest{
+		Title:       "Test Digest",
+		GeneratedAt: time.Now(),
+		Articles:    []*models.Article{first, second},
+		Sections: []*models.Section{
+			{Title: "Go", Articles: []*models.Article{first}},
+			{Title: "Rust", Articles: []*models.Article{second}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	opts := DefaultOptions()
+	if err := NewEPUBFormatter().Format(buf, digest, &opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open EPUB as zip: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, file := range zipReader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file.Name, err)
+		}
+		files[file.Name] = string(data)
+	}
+
+	for _, name := range []string{"section-1.xhtml", "section-2.xhtml", "article-2.xhtml"} {
+		if _, ok := files["EPUB/xhtml/"+name]; !ok {
+			t.Errorf("EPUB should contain %s", name)
+		}
+	}
+
+	toc := files["EPUB/xhtml/section0001.xhtml"]
+	for _, want := range []string{"Go</a> (1)", `href="section-2.xhtml"`, `href="article-2.xhtml"`} {
+		if !strings.Contains(toc, want) {
+			t.Errorf("Table of contents should contain %q, got:\n%s", want, toc)
+		}
+	}
+}
+
+func TestEPUBFormatter_Format_Language(t *testing.T) {
+	digest := &models.Digest{
+		Title:       "Test Digest",
+		GeneratedAt: time.Now(),
+		Articles: []*models.Article{
+			{
+				Title:    "Artikel",
+				Content:  "<p>Hallo</p>",
+				Metadata: map[string]any{models.MetadataLanguage: "de"},
+			},
+			{
+				Title:    "Noch ein Artikel",
+				Content:  "<p>Tschüss</p>",
+				Metadata: map[string]any{models.MetadataLanguage: "de"},
+			},
+			{
+				Title:    "Article",
+				Content:  "<p>Hello</p>",
+				Metadata: map[string]any{models.MetadataLanguage: "en"},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	opts := DefaultOptions()
+	if err := NewEPUBFormatter().Format(buf, digest, &opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open EPUB as zip: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, file := range zipReader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(data)
+	}
+
+	pkg := files["EPUB/package.opf"]
+	if !strings.Contains(pkg, "<dc:language>de</dc:language>") {
+		t.Errorf("Package should declare the most common language, got:\n%s", pkg)
+	}
+	article := files["EPUB/xhtml/article-3.xhtml"]
+	if !strings.Contains(article, `lang="en" xml:lang="en"`) {
+		t.Errorf("Article should declare its language, got:\n%s", article)
+	}
+}
+
 func TestEPUBFormatter_StoreImages(t *testing.T) {
 	// Create a test HTTP server to serve test images
 	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -269,6 +380,103 @@ func TestEPUBFormatter_StoreImages(t *testing.T) {
 	}
 }
 
+func TestEPUBFormatter_OptimizeImages(t *testing.T) {
+	photo := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	var photoPNG bytes.Buffer
+	png.Encode(&photoPNG, photo)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(photoPNG.Bytes())
+		case "/photo.avif":
+			w.Header().Set("Content-Type", "image/avif")
+			w.Write([]byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00"))
+		case "/huge.gif":
+			w.Header().Set("Content-Type", "image/gif")
+			w.Write([]byte("GIF89a\x30\x75\x30\x75\x00\x00\x00"))
+		case "/banner.png":
+			w.Header().Set("Content-Type", "image/png")
+			// A valid image, padded past max_bytes.
+			w.Write(append(photoPNG.Bytes(), make([]byte, 1<<20)...))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	digest := &models.Digest{
+		Title:       "Test Digest",
+		GeneratedAt: time.Now(),
+		Articles: []*models.Article{
+			{
+				Title: "Article",
+				URL:   testServer.URL,
+				Content: `<p>Text</p>
+					<img src="/photo.png" srcset="/photo-2x.png 2x" alt="Photo">
+					<img src="/pixel.gif" width="1" height="1">
+					<img src="/photo.avif" alt="Chart">
+					<img src="/huge.gif" alt="Poster">
+					<img src="/banner.png" alt="Banner">`,
+			},
+		},
+	}
+
+	imageOpts := images.Options{MaxWidth: 150, Quality: 75, MinSize: 16, MaxBytes: 1 << 16}
+	opts := &Options{StoreImages: true, Fetcher: fetcher.NewHTTPFetcher(), Images: &imageOpts}
+
+	buf := &bytes.Buffer{}
+	if err := NewEPUBFormatter().Format(buf, digest, opts); err != nil {
+		t.Fatalf("Failed to create EPUB: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open EPUB as zip: %v", err)
+	}
+
+	var imageFiles []string
+	var article string
+	for _, file := range zipReader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+
+		switch {
+		case strings.Contains(file.Name, "/images/"):
+			imageFiles = append(imageFiles, file.Name)
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Errorf("Image %s is not a JPEG: %v", file.Name, err)
+			} else if img.Bounds().Dx() != 150 {
+				t.Errorf("Image width = %d, want 150", img.Bounds().Dx())
+			}
+		case strings.HasSuffix(file.Name, "article-1.xhtml"):
+			article = string(data)
+		}
+	}
+
+	if len(imageFiles) != 1 {
+		t.Errorf("EPUB should contain only the photo, got %v", imageFiles)
+	}
+	if strings.Contains(article, "pixel.gif") || strings.Contains(article, "srcset") {
+		t.Errorf("Tracking pixel and srcset should be removed, got:\n%s", article)
+	}
+	if strings.Contains(article, "photo.avif") || !strings.Contains(article, "[Chart]") {
+		t.Errorf("Unsupported image should be replaced by its alt text, got:\n%s", article)
+	}
+	if strings.Contains(article, "huge.gif") || !strings.Contains(article, "[Poster]") {
+		t.Errorf("Oversized image should be replaced by its alt text, got:\n%s", article)
+	}
+	if strings.Contains(article, "banner.png") || !strings.Contains(article, "[Banner]") {
+		t.Errorf("Image over max_bytes should be replaced by its alt text, got:\n%s", article)
+	}
+}
+
 func TestResolveURL(t *testing.T) {
 	baseURLStr := "https://example.com/articles/news/"
 	baseURL, _ := url.Parse(baseURLStr)
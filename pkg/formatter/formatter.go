@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/images"
 	"github.com/shrik450/dijester/pkg/models"
 )
 
@@ -20,6 +21,10 @@ type Options struct {
 	// Markdown.
 	StoreImages bool
 
+	// Images optimizes stored images for e-readers. If nil, images are
+	// stored as they are. Doesn't apply to Markdown.
+	Images *images.Options
+
 	// Fetcher is a fetcher used to fetch images and other resources if
 	// required.
 	Fetcher fetcher.Fetcher
@@ -68,7 +73,7 @@ func New(name string) (Formatter, error) {
 }
 
 // OptionsFromConfig converts a configuration map to Options.
-func OptionsFromConfig(config map[string]any, fetcher fetcher.Fetcher) Options {
+func OptionsFromConfig(config map[string]any, fetcher fetcher.Fetcher) (Options, error) {
 	opts := DefaultOptions()
 
 	if includeSummary, ok := config["include_summary"].(bool); ok {
@@ -81,7 +86,15 @@ func OptionsFromConfig(config map[string]any, fetcher fetcher.Fetcher) Options {
 		opts.StoreImages = storeImages
 	}
 
+	if imagesConfig, ok := config["images"].(map[string]any); ok {
+		imageOpts, err := images.OptionsFromConfig(imagesConfig)
+		if err != nil {
+			return opts, fmt.Errorf("images: %w", err)
+		}
+		opts.Images = &imageOpts
+	}
+
 	opts.Fetcher = fetcher
 
-	return opts
+	return opts, nil
 }
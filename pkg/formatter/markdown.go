@@ -34,45 +34,130 @@ func (f *MarkdownFormatter) Format(w io.Writer, digest *models.Digest, opts *Opt
 	fmt.Fprintf(w, "Generated on: %s\n\n", digest.GeneratedAt.Format(time.RFC1123))
 	fmt.Fprintf(w, "## Contents\n\n")
 
-	for i, article := range digest.Articles {
-		fmt.Fprintf(w, "%d. [%s](#article-%d)\n", i+1, article.Title, i+1)
-	}
-	fmt.Fprintln(w, "")
+	if len(digest.Sections) == 0 {
+		for i, article := range digest.Articles {
+			fmt.Fprintf(
+				w,
+				"%d. [%s](#article-%d)%s\n",
+				i+1,
+				article.Title,
+				i+1,
+				tocReadingTime(article),
+			)
+		}
+		fmt.Fprintln(w, "")
 
-	for i, article := range digest.Articles {
-		fmt.Fprintf(w, "<a id=\"article-%d\"></a>\n", i+1)
-		fmt.Fprintf(w, "## %s\n\n", article.Title)
+		for i, article := range digest.Articles {
+			writeMarkdownArticle(w, article, i+1, 2, opts)
 
-		if article.Author != "" {
-			fmt.Fprintf(w, "**Author:** %s  \n", article.Author)
-		}
-		if !article.PublishedAt.IsZero() {
-			fmt.Fprintf(w, "**Published:** %s  \n", article.PublishedAt.Format(time.RFC1123))
+			if i < len(digest.Articles)-1 {
+				fmt.Fprintf(w, "---\n\n")
+			}
 		}
-		fmt.Fprintf(w, "**Source:** [%s](%s)  \n\n", article.SourceName, article.URL)
 
-		if len(article.Tags) > 0 {
-			fmt.Fprintf(w, "**Tags:** %s  \n\n", strings.Join(article.Tags, ", "))
-		}
+		return nil
+	}
 
-		if opts.IncludeSummary && article.Summary != "" {
-			fmt.Fprintf(w, "### Summary\n\n%s\n\n", article.Summary)
+	articleNum := 0
+	for i, section := range digest.Sections {
+		fmt.Fprintf(
+			w,
+			"- [%s](#section-%d) (%d)\n",
+			section.Title,
+			i+1,
+			len(section.Articles),
+		)
+		for _, article := range section.Articles {
+			articleNum++
+			fmt.Fprintf(
+				w,
+				"  %d. [%s](#article-%d)%s\n",
+				articleNum,
+				article.Title,
+				articleNum,
+				tocReadingTime(article),
+			)
 		}
+	}
+	fmt.Fprintln(w, "")
+
+	articleNum = 0
+	for i, section := range digest.Sections {
+		fmt.Fprintf(w, "<a id=\"section-%d\"></a>\n", i+1)
+		fmt.Fprintf(w, "## %s\n\n", section.Title)
+		fmt.Fprintf(w, "%s\n\n", articleCount(len(section.Articles)))
 
-		fmt.Fprintf(w, "### Content\n\n%s\n\n", HTMLToMarkdown(article.Content))
+		for j, article := range section.Articles {
+			articleNum++
+			writeMarkdownArticle(w, article, articleNum, 3, opts)
 
-		if opts.IncludeMetadata && len(article.Metadata) > 0 {
-			fmt.Fprintf(w, "### Metadata\n\n")
-			for key, value := range article.Metadata {
-				fmt.Fprintf(w, "- **%s:** %v\n", key, value)
+			if j < len(section.Articles)-1 {
+				fmt.Fprintf(w, "---\n\n")
 			}
-			fmt.Fprintln(w, "")
 		}
+	}
 
-		if i < len(digest.Articles)-1 {
-			fmt.Fprintf(w, "---\n\n")
+	return nil
+}
+
+// writeMarkdownArticle writes a single article, with its title as a heading of
+// the given level and its subheadings one level below.
+func writeMarkdownArticle(
+	w io.Writer,
+	article *models.Article,
+	num int,
+	level int,
+	opts *Options,
+) {
+	heading := strings.Repeat("#", level)
+	subheading := heading + "#"
+
+	fmt.Fprintf(w, "<a id=\"article-%d\"></a>\n", num)
+	fmt.Fprintf(w, "%s %s\n\n", heading, article.Title)
+
+	if article.Author != "" {
+		fmt.Fprintf(w, "**Author:** %s  \n", article.Author)
+	}
+	if !article.PublishedAt.IsZero() {
+		fmt.Fprintf(w, "**Published:** %s  \n", article.PublishedAt.Format(time.RFC1123))
+	}
+	if readingTime := readingTime(article); readingTime != "" {
+		fmt.Fprintf(w, "**Reading time:** %s  \n", readingTime)
+	}
+	fmt.Fprintf(w, "**Source:** [%s](%s)  \n\n", article.SourceName, article.URL)
+
+	if len(article.Tags) > 0 {
+		fmt.Fprintf(w, "**Tags:** %s  \n\n", strings.Join(article.Tags, ", "))
+	}
+
+	if opts.IncludeSummary && article.Summary != "" {
+		fmt.Fprintf(w, "%s Summary\n\n%s\n\n", subheading, article.Summary)
+	}
+
+	fmt.Fprintf(w, "%s Content\n\n%s\n\n", subheading, HTMLToMarkdown(article.Content))
+
+	if opts.IncludeMetadata && len(article.Metadata) > 0 {
+		fmt.Fprintf(w, "%s Metadata\n\n", subheading)
+		for key, value := range article.Metadata {
+			fmt.Fprintf(w, "- **%s:** %v\n", key, value)
 		}
+		fmt.Fprintln(w, "")
 	}
+}
 
-	return nil
+// tocReadingTime returns the reading time shown next to an article in the
+// table of contents, if the article has one.
+func tocReadingTime(article *models.Article) string {
+	if readingTime := readingTime(article); readingTime != "" {
+		return " · " + readingTime
+	}
+	return ""
+}
+
+// articleCount formats a number of articles for display.
+func articleCount(n int) string {
+	if n == 1 {
+		return "1 article"
+	}
+	return fmt.Sprintf("%d articles", n)
 }
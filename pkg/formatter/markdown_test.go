@@ -132,3 +132,75 @@ func TestMarkdownFormatter_Format_NoSummary(t *testing.T) {
 		t.Errorf("Output should not contain '%s' when summaries are disabled", notExpectedLine)
 	}
 }
+
+func TestMarkdownFormatter_Format_Sections(t *testing.T) {
+	f := NewMarkdownFormatter()
+	var buf bytes.Buffer
+
+	first := &models.Article{Title: "Article 1", Content: "<p>One</p>", Summary: "S1"}
+	second := &models.Article{Title: "Article 2", Content: "<p>Two</p>"}
+	third := &models.Article{Title: "Article 3", Content: "<p>Three</p>"}
+	digest := &models.Digest{
+		Title:       "Test Digest",
+		GeneratedAt: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Articles:    []*models.Article{first, second, third},
+		Sections: []*models.Section{
+			{Title: "Go", Articles: []*models.Article{first, second}},
+			{Title: "Rust", Articles: []*models.Article{third}},
+		},
+	}
+
+	if err := f.Format(&buf, digest, nil); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	result := buf.String()
+	expected := []string{
+		"- [Go](#section-1) (2)\n  1. [Article 1](#article-1)\n  2. [Article 2](#article-2)\n",
+		"- [Rust](#section-2) (1)\n  3. [Article 3](#article-3)\n",
+		"<a id=\"section-1\"></a>\n## Go\n\n2 articles\n",
+		"<a id=\"section-2\"></a>\n## Rust\n\n1 article\n",
+		"<a id=\"article-3\"></a>\n### Article 3\n",
+		"#### Summary\n\nS1",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("Output should contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestMarkdownFormatter_Format_ReadingTime(t *testing.T) {
+	f := NewMarkdownFormatter()
+	var buf bytes.Buffer
+
+	digest := &models.Digest{
+		Title: "Test Digest",
+		Articles: []*models.Article{
+			{
+				Title:    "Long read",
+				Content:  "<p>Content</p>",
+				Metadata: map[string]any{models.MetadataReadingTime: 7},
+			},
+			{Title: "No stats", Content: "<p>Content</p>"},
+		},
+	}
+
+	if err := f.Format(&buf, digest, nil); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	result := buf.String()
+	for _, want := range []string{
+		"1. [Long read](#article-1) · 7 min read\n",
+		"2. [No stats](#article-2)\n",
+		"**Reading time:** 7 min read",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Output should contain %q, got:\n%s", want, result)
+		}
+	}
+	if strings.Count(result, "min read") != 2 {
+		t.Errorf("Only the article with stats should show a reading time, got:\n%s", result)
+	}
+}
@@ -0,0 +1,292 @@
+// Package images optimizes images for e-readers, which have small, often
+// grayscale screens and only support a few image formats.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"math"
+	"regexp"
+
+	// Register the decoders of formats images may arrive in.
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrTooSmall indicates that an image is so small it is most likely a
+// tracking pixel or a spacer, and should be dropped.
+var ErrTooSmall = errors.New("image is too small")
+
+// ErrUnsupportedFormat indicates that an image is in a format that can't be
+// decoded, such as AVIF, and can't be converted to one e-readers support.
+// There's no AVIF decoder in Go, so AVIF images are always unsupported.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// ErrTooLarge indicates that an image has more than MaxPixels pixels, or
+// more than Options.MaxBytes bytes, so decoding it could run out of memory.
+var ErrTooLarge = errors.New("image is too large")
+
+// MaxPixels is the most pixels an image can have to be decoded or
+// rasterized, about 160MB as RGBA.
+const MaxPixels = 40_000_000
+
+// Options controls how images are optimized.
+type Options struct {
+	// MaxWidth is the maximum width of images, in pixels (0 means no limit)
+	MaxWidth int
+
+	// MaxHeight is the maximum height of images, in pixels (0 means no limit)
+	MaxHeight int
+
+	// Grayscale converts images to grayscale
+	Grayscale bool
+
+	// Quality is the JPEG quality images are encoded with, from 1 to 100
+	Quality int
+
+	// MinSize drops images whose width or height is at most this many pixels
+	MinSize int
+
+	// MaxBytes is the size of the largest image downloaded, in bytes (0
+	// means no limit)
+	MaxBytes int
+}
+
+// DefaultOptions returns options suited to common 6" e-ink readers.
+func DefaultOptions() Options {
+	return Options{
+		MaxWidth:  1072,
+		MaxHeight: 1448,
+		Grayscale: true,
+		Quality:   75,
+		MinSize:   16,
+		MaxBytes:  20 << 20,
+	}
+}
+
+// OptionsFromConfig converts a configuration map to Options, starting from
+// DefaultOptions.
+func OptionsFromConfig(config map[string]any) (Options, error) {
+	opts := DefaultOptions()
+
+	ints := map[string]*int{
+		"max_width":  &opts.MaxWidth,
+		"max_height": &opts.MaxHeight,
+		"quality":    &opts.Quality,
+		"min_size":   &opts.MinSize,
+		"max_bytes":  &opts.MaxBytes,
+	}
+	for key, dst := range ints {
+		v, ok := config[key]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case int:
+			*dst = v
+		case int64:
+			*dst = int(v)
+		default:
+			return opts, fmt.Errorf("%s must be an integer, got %v", key, v)
+		}
+	}
+
+	if v, ok := config["grayscale"]; ok {
+		grayscale, ok := v.(bool)
+		if !ok {
+			return opts, fmt.Errorf("grayscale must be a boolean, got %v", v)
+		}
+		opts.Grayscale = grayscale
+	}
+
+	return opts, opts.Validate()
+}
+
+// Validate checks the options for invalid values.
+func (o Options) Validate() error {
+	if o.MaxWidth < 0 || o.MaxHeight < 0 || o.MinSize < 0 || o.MaxBytes < 0 {
+		return errors.New("max_width, max_height, min_size and max_bytes must not be negative")
+	}
+	if o.Quality < 1 || o.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100, got %d", o.Quality)
+	}
+	return nil
+}
+
+// IsTooSmall reports whether an image of the given size should be dropped.
+// Unknown dimensions are passed as 0 and never count as too small.
+func (o Options) IsTooSmall(width, height int) bool {
+	return (width > 0 && width <= o.MinSize) || (height > 0 && height <= o.MinSize)
+}
+
+// LimitWriter returns a writer to w that fails with ErrTooLarge once more than
+// opts.MaxBytes bytes are written to it, so downloading an image too large
+// to optimize can be stopped before all of it is in memory.
+func (o Options) LimitWriter(w io.Writer) io.Writer {
+	if o.MaxBytes <= 0 {
+		return w
+	}
+	return &limitedWriter{w: w, max: o.MaxBytes}
+}
+
+type limitedWriter struct {
+	w       io.Writer
+	max     int
+	written int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.written+len(p) > l.max {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, l.max)
+	}
+	n, err := l.w.Write(p)
+	l.written += n
+	return n, err
+}
+
+// Optimize decodes an image in any supported format (JPEG, PNG, GIF, WebP or
+// SVG), scales it down to fit within the maximum size, converts it to
+// grayscale if configured and encodes it as a JPEG. Transparent areas become
+// white. An image that is already a JPEG small enough to keep as it is is
+// returned unchanged when it wouldn't get any smaller. Images with more than
+// MaxPixels pixels are rejected with ErrTooLarge before they are decoded.
+func Optimize(data []byte, opts Options) ([]byte, error) {
+	var img image.Image
+	format := "svg"
+	if isSVG(data) {
+		var err error
+		img, err = rasterizeSVG(data, opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		config, configFormat, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+		}
+		if opts.IsTooSmall(config.Width, config.Height) {
+			return nil, ErrTooSmall
+		}
+		if isTooLarge(float64(config.Width), float64(config.Height)) {
+			return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+		}
+
+		img, _, err = image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s image: %w", configFormat, err)
+		}
+		format = configFormat
+	}
+
+	bounds := img.Bounds()
+	width, height := fitWithin(bounds.Dx(), bounds.Dy(), opts.MaxWidth, opts.MaxHeight)
+	resized := width != bounds.Dx() || height != bounds.Dy()
+
+	var out draw.Image
+	if opts.Grayscale {
+		out = image.NewGray(image.Rect(0, 0, width, height))
+	} else {
+		out = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	if resized {
+		xdraw.CatmullRom.Scale(out, out.Bounds(), img, bounds, xdraw.Over, nil)
+	} else {
+		draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: opts.Quality}); err != nil {
+		return nil, fmt.Errorf("encoding image: %w", err)
+	}
+
+	if format == "jpeg" && !resized && !opts.Grayscale && buf.Len() >= len(data) {
+		return data, nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isTooLarge reports whether an image of the given size has more than
+// MaxPixels pixels.
+func isTooLarge(width, height float64) bool {
+	return width*height > MaxPixels
+}
+
+// fitWithin scales a size down, keeping its aspect ratio, so it fits within
+// a maximum width and height. A maximum of 0 means no limit.
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = math.Min(scale, float64(maxWidth)/float64(width))
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = math.Min(scale, float64(maxHeight)/float64(height))
+	}
+	if scale == 1 {
+		return width, height
+	}
+
+	return max(int(math.Round(float64(width)*scale)), 1),
+		max(int(math.Round(float64(height)*scale)), 1)
+}
+
+// svgTag matches the start of an SVG document, up to its root element.
+var svgTag = regexp.MustCompile(
+	`(?i)^\s*(<\?xml[^>]*>\s*)?(<!--.*?-->\s*)*(<!DOCTYPE[^>]*>\s*)?<svg`,
+)
+
+// isSVG reports whether data looks like an SVG document.
+func isSVG(data []byte) bool {
+	return svgTag.Match(data[:min(len(data), 1024)])
+}
+
+// rasterizeSVG renders an SVG at its own size, scaled down to fit within the
+// maximum size.
+func rasterizeSVG(data []byte, opts Options) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	viewWidth, viewHeight := icon.ViewBox.W, icon.ViewBox.H
+	if viewWidth <= 0 || viewHeight <= 0 {
+		return nil, fmt.Errorf("%w: SVG has no size", ErrUnsupportedFormat)
+	}
+	if opts.IsTooSmall(int(viewWidth), int(viewHeight)) {
+		return nil, ErrTooSmall
+	}
+	// Sizes this large can't be rasterized even when scaled down, and would
+	// overflow converting to int.
+	if viewWidth > math.MaxInt32 || viewHeight > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: %gx%g", ErrTooLarge, viewWidth, viewHeight)
+	}
+
+	width, height := fitWithin(
+		int(math.Ceil(viewWidth)),
+		int(math.Ceil(viewHeight)),
+		opts.MaxWidth,
+		opts.MaxHeight,
+	)
+	if isTooLarge(float64(width), float64(height)) {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, width, height)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	icon.SetTarget(0, 0, float64(width), float64(height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+
+	return img, nil
+}
@@ -0,0 +1,203 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// solidWebP is a 40x30 lossless WebP filled with a single color.
+var solidWebP = []byte{
+	0x52, 0x49, 0x46, 0x46, 0x18, 0x00, 0x00, 0x00, 0x57, 0x45, 0x42, 0x50, 0x56, 0x50, 0x38,
+	0x4c, 0x0c, 0x00, 0x00, 0x00, 0x2f, 0x27, 0x40, 0x07, 0x00, 0x28, 0x72, 0x3d, 0xca, 0xd3,
+	0xff, 0x00,
+}
+
+const testSVG = `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100" viewBox="0 0 200 100">
+	<rect x="0" y="0" width="100" height="100" fill="black"/>
+</svg>`
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encoding PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		opts       Options
+		wantWidth  int
+		wantHeight int
+		wantGray   bool
+	}{
+		{
+			name:       "downscales and converts to grayscale",
+			data:       encodePNG(t, 400, 200),
+			opts:       Options{MaxWidth: 100, MaxHeight: 100, Grayscale: true, Quality: 75},
+			wantWidth:  100,
+			wantHeight: 50,
+			wantGray:   true,
+		},
+		{
+			name:       "keeps small images at their size",
+			data:       encodePNG(t, 40, 20),
+			opts:       Options{MaxWidth: 100, MaxHeight: 100, Quality: 75},
+			wantWidth:  40,
+			wantHeight: 20,
+		},
+		{
+			name:       "converts WebP",
+			data:       solidWebP,
+			opts:       DefaultOptions(),
+			wantWidth:  40,
+			wantHeight: 30,
+			wantGray:   true,
+		},
+		{
+			name:       "rasterizes SVG",
+			data:       []byte(testSVG),
+			opts:       Options{MaxWidth: 100, Quality: 75},
+			wantWidth:  100,
+			wantHeight: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Optimize(tt.data, tt.opts)
+			if err != nil {
+				t.Fatalf("Optimize() returned error: %v", err)
+			}
+
+			img, err := jpeg.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("Optimize() did not return a JPEG: %v", err)
+			}
+
+			bounds := img.Bounds()
+			if bounds.Dx() != tt.wantWidth || bounds.Dy() != tt.wantHeight {
+				t.Errorf(
+					"size = %dx%d, want %dx%d",
+					bounds.Dx(),
+					bounds.Dy(),
+					tt.wantWidth,
+					tt.wantHeight,
+				)
+			}
+
+			if _, gray := img.(*image.Gray); gray != tt.wantGray {
+				t.Errorf("grayscale = %v, want %v", gray, tt.wantGray)
+			}
+		})
+	}
+}
+
+func TestOptimize_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		opts *Options
+		want error
+	}{
+		{name: "tracking pixel", data: encodePNG(t, 1, 1), want: ErrTooSmall},
+		{name: "thin spacer", data: encodePNG(t, 300, 2), want: ErrTooSmall},
+		{
+			name: "AVIF",
+			data: []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00"),
+			want: ErrUnsupportedFormat,
+		},
+		{
+			// Only the header of a 30000x30000 GIF, which is all that's read.
+			name: "huge image",
+			data: []byte("GIF89a\x30\x75\x30\x75\x00\x00\x00"),
+			want: ErrTooLarge,
+		},
+		{
+			name: "huge SVG without a maximum size",
+			data: []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100000 100000">
+				<rect width="10" height="10"/></svg>`),
+			opts: &Options{Quality: 75},
+			want: ErrTooLarge,
+		},
+		{
+			name: "SVG too large to convert",
+			data: []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1e20 1e20">
+				<rect width="10" height="10"/></svg>`),
+			want: ErrTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			if tt.opts != nil {
+				opts = *tt.opts
+			}
+			if _, err := Optimize(tt.data, opts); !errors.Is(err, tt.want) {
+				t.Errorf("Optimize() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(map[string]any{
+		"max_width": int64(600),
+		"grayscale": false,
+	})
+	if err != nil {
+		t.Fatalf("OptionsFromConfig() returned error: %v", err)
+	}
+	if opts.MaxWidth != 600 || opts.Grayscale || opts.Quality != DefaultOptions().Quality {
+		t.Errorf("OptionsFromConfig() = %+v, want defaults with overrides", opts)
+	}
+
+	invalid := []map[string]any{
+		{"quality": int64(0)},
+		{"max_width": "wide"},
+		{"grayscale": "yes"},
+		{"max_bytes": int64(-1)},
+	}
+	for _, config := range invalid {
+		if _, err := OptionsFromConfig(config); err == nil {
+			t.Errorf("OptionsFromConfig(%v) expected error, got nil", config)
+		}
+	}
+}
+
+func TestOptions_LimitWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := Options{MaxBytes: 10}.LimitWriter(&buf)
+
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Write() within the limit returned error: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Write() over the limit error = %v, want ErrTooLarge", err)
+	}
+	if buf.String() != "0123456789" {
+		t.Errorf("written = %q, want only the bytes within the limit", buf.String())
+	}
+
+	if w := (Options{}).LimitWriter(&buf); w != &buf {
+		t.Error("LimitWriter() without MaxBytes should return the writer as it is")
+	}
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Strategies for allocating the digest budget across sources.
+const (
+	// BudgetRoundRobin takes one article from each source in turn
+	BudgetRoundRobin = "round_robin"
+
+	// BudgetProportional gives each source a share of the budget proportional
+	// to the number of articles it contributed
+	BudgetProportional = "proportional"
+
+	// BudgetByScore takes the highest scoring articles first, regardless of
+	// source
+	BudgetByScore = "by_score"
+)
+
+// Budget limits the size of a digest.
+type Budget struct {
+	// MaxArticles is the maximum number of articles (0 means no limit)
+	MaxArticles int
+
+	// MaxWords is the maximum total number of words (0 means no limit)
+	MaxWords int
+
+	// Strategy decides which articles are kept when the budget is exceeded.
+	// Defaults to BudgetRoundRobin.
+	Strategy string
+}
+
+// Validate checks the budget for invalid values.
+func (b Budget) Validate() error {
+	if b.MaxArticles < 0 {
+		return fmt.Errorf("max_articles must not be negative, got %d", b.MaxArticles)
+	}
+
+	if b.MaxWords < 0 {
+		return fmt.Errorf("max_words must not be negative, got %d", b.MaxWords)
+	}
+
+	switch b.Strategy {
+	case "", BudgetRoundRobin, BudgetProportional, BudgetByScore:
+	default:
+		return fmt.Errorf(
+			"invalid budget strategy %q: must be one of %q, %q or %q",
+			b.Strategy,
+			BudgetRoundRobin,
+			BudgetProportional,
+			BudgetByScore,
+		)
+	}
+
+	return nil
+}
+
+// ApplyBudget selects articles to fit within the budget. Articles are
+// considered in the order given by the budget's strategy and kept while they
+// fit; an article that would exceed MaxWords is skipped in favour of shorter
+// ones. The returned slice preserves the original relative order of the
+// selected articles.
+func ApplyBudget(articles []*Article, budget Budget) ([]*Article, error) {
+	if err := budget.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(articles) == 0 || (budget.MaxArticles == 0 && budget.MaxWords == 0) {
+		return articles, nil
+	}
+
+	var order []int
+	switch budget.Strategy {
+	case "", BudgetRoundRobin:
+		order = roundRobinOrder(articles)
+	case BudgetProportional:
+		order = proportionalOrder(articles)
+	case BudgetByScore:
+		order = scoreOrder(articles)
+	}
+
+	selected := make([]bool, len(articles))
+	count, words := 0, 0
+	for _, i := range order {
+		if budget.MaxArticles > 0 && count >= budget.MaxArticles {
+			break
+		}
+
+		articleWords := WordCount(articles[i])
+		if budget.MaxWords > 0 && words+articleWords > budget.MaxWords {
+			continue
+		}
+
+		selected[i] = true
+		count++
+		words += articleWords
+	}
+
+	result := make([]*Article, 0, count)
+	for i, article := range articles {
+		if selected[i] {
+			result = append(result, article)
+		}
+	}
+
+	return result, nil
+}
+
+// groupBySource returns the indices of the articles from each source, with
+// sources ordered by name so the allocation is deterministic.
+func groupBySource(articles []*Article) [][]int {
+	groups := make(map[string][]int)
+	names := make([]string, 0)
+	for i, article := range articles {
+		if _, ok := groups[article.SourceName]; !ok {
+			names = append(names, article.SourceName)
+		}
+		groups[article.SourceName] = append(groups[article.SourceName], i)
+	}
+
+	slices.Sort(names)
+
+	result := make([][]int, len(names))
+	for i, name := range names {
+		result[i] = groups[name]
+	}
+
+	return result
+}
+
+func roundRobinOrder(articles []*Article) []int {
+	groups := groupBySource(articles)
+	order := make([]int, 0, len(articles))
+
+	for round := 0; len(order) < len(articles); round++ {
+		for _, group := range groups {
+			if round < len(group) {
+				order = append(order, group[round])
+			}
+		}
+	}
+
+	return order
+}
+
+// proportionalOrder orders articles so that any prefix of the order contains
+// articles from each source roughly in proportion to how many that source
+// contributed. The k-th article of a source with n articles is placed at
+// (k+0.5)/n.
+func proportionalOrder(articles []*Article) []int {
+	type position struct {
+		index int
+		group int
+		key   float64
+	}
+
+	groups := groupBySource(articles)
+	positions := make([]position, 0, len(articles))
+	for g, group := range groups {
+		for k, i := range group {
+			key := (float64(k) + 0.5) / float64(len(group))
+			positions = append(positions, position{index: i, group: g, key: key})
+		}
+	}
+
+	slices.SortStableFunc(positions, func(a, b position) int {
+		if c := cmp.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.group, b.group)
+	})
+
+	order := make([]int, len(positions))
+	for i, p := range positions {
+		order[i] = p.index
+	}
+
+	return order
+}
+
+// scoreOrder orders articles by their "score" metadata, highest first.
+// Articles without a score come last, in their original order.
+func scoreOrder(articles []*Article) []int {
+	order := make([]int, len(articles))
+	for i := range order {
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		scoreA, okA := numericMetadata(articles[a], "score")
+		scoreB, okB := numericMetadata(articles[b], "score")
+		switch {
+		case okA && okB:
+			return cmp.Compare(scoreB, scoreA)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return order
+}
+
+// numericMetadata returns a metadata value as a float64, if it is numeric.
+func numericMetadata(article *Article, key string) (float64, bool) {
+	switch v := article.Metadata[key].(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
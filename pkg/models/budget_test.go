@@ -0,0 +1,112 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyBudget(t *testing.T) {
+	newArticles := func() []*Article {
+		short := "<p>one two</p>"
+		one := "<p>one</p>"
+		return []*Article{
+			{Title: "a1", SourceName: "a", Content: short, Metadata: map[string]any{"score": 5}},
+			{Title: "a2", SourceName: "a", Content: short, Metadata: map[string]any{"score": 50}},
+			{Title: "a3", SourceName: "a", Content: short, Metadata: map[string]any{"score": 1}},
+			{Title: "a4", SourceName: "a", Content: short},
+			{Title: "b1", SourceName: "b", Content: "<p>one two three four five</p>"},
+			{Title: "b2", SourceName: "b", Content: one, Metadata: map[string]any{"score": 20}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		budget Budget
+		want   []string
+	}{
+		{
+			name:   "no budget",
+			budget: Budget{},
+			want:   []string{"a1", "a2", "a3", "a4", "b1", "b2"},
+		},
+		{
+			name:   "round robin",
+			budget: Budget{MaxArticles: 3, Strategy: BudgetRoundRobin},
+			want:   []string{"a1", "a2", "b1"},
+		},
+		{
+			name:   "default strategy is round robin",
+			budget: Budget{MaxArticles: 2},
+			want:   []string{"a1", "b1"},
+		},
+		{
+			name:   "proportional",
+			budget: Budget{MaxArticles: 3, Strategy: BudgetProportional},
+			want:   []string{"a1", "a2", "b1"},
+		},
+		{
+			name:   "proportional keeps smaller source represented",
+			budget: Budget{MaxArticles: 4, Strategy: BudgetProportional},
+			want:   []string{"a1", "a2", "a3", "b1"},
+		},
+		{
+			name:   "by score",
+			budget: Budget{MaxArticles: 3, Strategy: BudgetByScore},
+			want:   []string{"a1", "a2", "b2"},
+		},
+		{
+			name:   "max words skips articles that don't fit",
+			budget: Budget{MaxWords: 5, Strategy: BudgetRoundRobin},
+			want:   []string{"a1", "a2", "b2"},
+		},
+		{
+			name:   "max words and max articles",
+			budget: Budget{MaxArticles: 2, MaxWords: 5, Strategy: BudgetByScore},
+			want:   []string{"a2", "b2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyBudget(newArticles(), tt.budget)
+			if err != nil {
+				t.Fatalf("ApplyBudget() returned error: %v", err)
+			}
+
+			titles := make([]string, len(got))
+			for i, article := range got {
+				titles[i] = article.Title
+			}
+
+			if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ApplyBudget() = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudget_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		budget    Budget
+		mustError bool
+	}{
+		{name: "empty", budget: Budget{}},
+		{name: "valid", budget: Budget{MaxArticles: 10, MaxWords: 1000, Strategy: BudgetByScore}},
+		{name: "negative articles", budget: Budget{MaxArticles: -1}, mustError: true},
+		{name: "negative words", budget: Budget{MaxWords: -1}, mustError: true},
+		{name: "unknown strategy", budget: Budget{Strategy: "random"}, mustError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.budget.Validate()
+			if tt.mustError && err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+			if !tt.mustError && err != nil {
+				t.Errorf("Validate() returned unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"cmp"
 	"fmt"
+	"net/url"
+	"reflect"
 	"slices"
 	"strings"
 	"time"
@@ -49,6 +51,10 @@ type Digest struct {
 	// Articles is the collection of articles in this digest
 	Articles []*Article
 
+	// Sections groups the articles when the digest is grouped by a property.
+	// It is empty for ungrouped digests; Articles always holds every article.
+	Sections []*Section
+
 	// Metadata contains any additional digest-specific metadata
 	Metadata map[string]interface{}
 }
@@ -61,6 +67,9 @@ var allowedSortFields = []string{
 	"Content",
 	"Summary",
 	"SourceName",
+	"WordCount",
+	"ReadingTime",
+	"Domain",
 }
 
 // DeduplicateArticlesByURL removes duplicate articles with the same URL.
@@ -84,6 +93,16 @@ func DeduplicateArticlesByURL(articles []*Article) []*Article {
 	return deduped
 }
 
+// Policies for where articles without a value for a sort field are placed.
+const (
+	NullsFirst = "first"
+	NullsLast  = "last"
+)
+
+// metadataSortPrefix is the prefix of sort fields that refer to a metadata
+// key, e.g. "Metadata.score".
+const metadataSortPrefix = "Metadata."
+
 // SortField represents a field to sort articles by, along with its direction.
 type SortField struct {
 	// Name is the name of the field to sort by
@@ -91,30 +110,45 @@ type SortField struct {
 
 	// Direction is either "asc" or "desc"
 	Direction string
+
+	// Nulls is either "first" or "last", and controls where articles without
+	// a value for this field are placed regardless of direction
+	Nulls string
 }
 
-// ParseSortField parses a sort field string in the format "FieldName:direction".
-// If no direction is specified, defaults to "asc".
+// ParseSortField parses a sort field string in the format
+// "FieldName:direction:nulls_policy", where the direction and nulls policy
+// are optional. FieldName can be an article field, a computed key such as
+// "WordCount", or "Metadata.key" for source-specific metadata.
+// If no direction is specified, defaults to "asc". Nulls default to last.
 // Returns a SortField struct.
 func ParseSortField(fieldStr string) (SortField, error) {
 	field := SortField{
 		Direction: "asc",
+		Nulls:     NullsLast,
 	}
 
-	if strings.Contains(fieldStr, ":") {
-		parts := strings.Split(fieldStr, ":")
-		field.Name = parts[0]
-		if len(parts) > 2 {
-			return SortField{}, fmt.Errorf("invalid sort field format: %s", fieldStr)
-		}
-		if len(parts) == 2 {
-			field.Direction = parts[1]
+	parts := strings.Split(fieldStr, ":")
+	if len(parts) > 3 {
+		return SortField{}, fmt.Errorf("invalid sort field format: %s", fieldStr)
+	}
+
+	field.Name = parts[0]
+	if len(parts) >= 2 {
+		field.Direction = parts[1]
+	}
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "nulls_first":
+			field.Nulls = NullsFirst
+		case "nulls_last":
+			field.Nulls = NullsLast
+		default:
+			return SortField{}, fmt.Errorf("invalid nulls policy: %s", parts[2])
 		}
-	} else {
-		field.Name = fieldStr
 	}
 
-	if field.Name == "" || !slices.Contains(allowedSortFields, field.Name) {
+	if !isValidSortField(field.Name) {
 		return SortField{}, fmt.Errorf("invalid sort field: %s", field.Name)
 	}
 
@@ -125,9 +159,18 @@ func ParseSortField(fieldStr string) (SortField, error) {
 	return field, nil
 }
 
+func isValidSortField(name string) bool {
+	if key, ok := strings.CutPrefix(name, metadataSortPrefix); ok {
+		return key != ""
+	}
+
+	return slices.Contains(allowedSortFields, name)
+}
+
 // SortArticles sorts a slice of articles based on the provided sort fields.
-// Each sort field can be in the format "FieldName" or "FieldName:direction"
-// where direction is either "asc" or "desc".
+// Each sort field can be in the format "FieldName", "FieldName:direction" or
+// "FieldName:direction:nulls_policy" where direction is either "asc" or
+// "desc" and the nulls policy is either "nulls_first" or "nulls_last".
 func SortArticles(articles []*Article, sortFields []string) error {
 	if len(articles) <= 1 || len(sortFields) == 0 {
 		return nil
@@ -142,10 +185,37 @@ func SortArticles(articles []*Article, sortFields []string) error {
 		parsedFields[i] = field
 	}
 
-	compareArticles := func(a, b *Article) int {
-		for _, field := range parsedFields {
-			cmpVal := cmpByField(a, b, field.Name)
+	// Computed keys can be expensive, so compute every key once up front.
+	type keyedArticle struct {
+		article *Article
+		keys    []sortValue
+	}
+
+	keyed := make([]keyedArticle, len(articles))
+	for i, article := range articles {
+		keys := make([]sortValue, len(parsedFields))
+		for j, field := range parsedFields {
+			keys[j] = sortKey(article, field.Name)
+		}
+		keyed[i] = keyedArticle{article: article, keys: keys}
+	}
+
+	compareArticles := func(a, b keyedArticle) int {
+		for i, field := range parsedFields {
+			keyA, keyB := a.keys[i], b.keys[i]
+
+			if keyA.null || keyB.null {
+				if keyA.null && keyB.null {
+					continue
+				}
+				nullFirst := keyA.null == (field.Nulls == NullsFirst)
+				if nullFirst {
+					return -1
+				}
+				return 1
+			}
 
+			cmpVal := keyA.compare(keyB)
 			if cmpVal != 0 {
 				if field.Direction == "desc" {
 					return -cmpVal
@@ -157,28 +227,121 @@ func SortArticles(articles []*Article, sortFields []string) error {
 		return 0
 	}
 
-	slices.SortStableFunc(articles, compareArticles)
+	slices.SortStableFunc(keyed, compareArticles)
+
+	for i, k := range keyed {
+		articles[i] = k.article
+	}
 
 	return nil
 }
 
-func cmpByField(a, b *Article, fieldName string) int {
+// sortValue is the value of an article for a sort field. Numeric values sort
+// before strings when a field has values of both types.
+type sortValue struct {
+	null    bool
+	numeric bool
+	num     float64
+	str     string
+}
+
+func (v sortValue) compare(other sortValue) int {
+	switch {
+	case v.numeric && other.numeric:
+		return cmp.Compare(v.num, other.num)
+	case v.numeric:
+		return -1
+	case other.numeric:
+		return 1
+	}
+
+	return cmp.Compare(v.str, other.str)
+}
+
+func stringValue(s string) sortValue {
+	return sortValue{str: s}
+}
+
+func numberValue(n float64) sortValue {
+	return sortValue{numeric: true, num: n}
+}
+
+func sortKey(article *Article, fieldName string) sortValue {
+	if key, ok := strings.CutPrefix(fieldName, metadataSortPrefix); ok {
+		return metadataSortValue(article.Metadata[key])
+	}
+
 	switch fieldName {
 	case "Title":
-		return cmp.Compare(a.Title, b.Title)
+		return stringValue(article.Title)
 	case "Author":
-		return cmp.Compare(a.Author, b.Author)
+		return stringValue(article.Author)
 	case "PublishedAt":
-		return cmp.Compare(a.PublishedAt.Unix(), b.PublishedAt.Unix())
+		if article.PublishedAt.IsZero() {
+			return sortValue{null: true}
+		}
+		return numberValue(float64(article.PublishedAt.Unix()))
 	case "URL":
-		return cmp.Compare(a.URL, b.URL)
+		return stringValue(article.URL)
 	case "Content":
-		return cmp.Compare(a.Content, b.Content)
+		return stringValue(article.Content)
 	case "Summary":
-		return cmp.Compare(a.Summary, b.Summary)
+		return stringValue(article.Summary)
 	case "SourceName":
-		return cmp.Compare(a.SourceName, b.SourceName)
+		return stringValue(article.SourceName)
+	case "WordCount":
+		return numberValue(float64(WordCount(article)))
+	case "ReadingTime":
+		return numberValue(float64(ReadingTime(article)))
+	case "Domain":
+		return stringValue(Domain(article.URL))
 	default:
-		return 0
+		return sortValue{null: true}
 	}
 }
+
+// metadataSortValue converts a metadata value to a sort value, inferring
+// whether to compare it as a number or a string from its type.
+func metadataSortValue(v any) sortValue {
+	switch v := v.(type) {
+	case nil:
+		return sortValue{null: true}
+	case string:
+		return stringValue(v)
+	case bool:
+		if v {
+			return numberValue(1)
+		}
+		return numberValue(0)
+	case time.Time:
+		if v.IsZero() {
+			return sortValue{null: true}
+		}
+		return numberValue(float64(v.Unix()))
+	case time.Duration:
+		return numberValue(float64(v))
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return numberValue(float64(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return numberValue(float64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return numberValue(rv.Float())
+	}
+
+	return stringValue(fmt.Sprint(v))
+}
+
+// Domain returns the host name of a URL, lowercased and without a leading
+// "www.".
+func Domain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
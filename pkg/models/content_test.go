@@ -139,6 +139,30 @@ func TestParseSortField(t *testing.T) {
 			want:      SortField{},
 			mustError: true,
 		},
+		{
+			name:      "metadata field",
+			fieldStr:  "Metadata.score:desc",
+			want:      SortField{Name: "Metadata.score", Direction: "desc", Nulls: NullsLast},
+			mustError: false,
+		},
+		{
+			name:      "computed field with nulls policy",
+			fieldStr:  "WordCount:asc:nulls_first",
+			want:      SortField{Name: "WordCount", Direction: "asc", Nulls: NullsFirst},
+			mustError: false,
+		},
+		{
+			name:      "empty metadata key errors",
+			fieldStr:  "Metadata.",
+			want:      SortField{},
+			mustError: true,
+		},
+		{
+			name:      "too many colons errors",
+			fieldStr:  "Title:asc:nulls_last:extra",
+			want:      SortField{},
+			mustError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -154,6 +178,10 @@ func TestParseSortField(t *testing.T) {
 			if got.Name != tt.want.Name || got.Direction != tt.want.Direction {
 				t.Errorf("ParseSortField() = %v, want %v", got, tt.want)
 			}
+
+			if tt.want.Nulls != "" && got.Nulls != tt.want.Nulls {
+				t.Errorf("ParseSortField() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -311,3 +339,108 @@ func TestSortArticles(t *testing.T) {
 		})
 	}
 }
+
+func TestSortArticles_MetadataAndComputedKeys(t *testing.T) {
+	articles := func() []*Article {
+		return []*Article{
+			{
+				Title:    "low",
+				URL:      "https://www.b.example.com/1",
+				Content:  "<p>one two three</p>",
+				Metadata: map[string]any{"score": 5},
+			},
+			{
+				Title:    "unscored",
+				URL:      "https://c.example.com/2",
+				Content:  "<p>one</p>",
+				Metadata: map[string]any{},
+			},
+			{
+				Title:    "high",
+				URL:      "https://a.example.com/3",
+				Content:  "<p>one two</p>",
+				Metadata: map[string]any{"score": int64(300), MetadataReadingTime: 2},
+			},
+			{
+				Title:    "float",
+				URL:      "https://d.example.com/4",
+				Content:  "<p>one two three four</p>",
+				Metadata: map[string]any{"score": 40.5, MetadataWordCount: 450},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		sortFields []string
+		want       []string
+	}{
+		{
+			name:       "numeric metadata descending with nulls last",
+			sortFields: []string{"Metadata.score:desc"},
+			want:       []string{"high", "float", "low", "unscored"},
+		},
+		{
+			name:       "numeric metadata ascending with nulls first",
+			sortFields: []string{"Metadata.score:asc:nulls_first"},
+			want:       []string{"unscored", "low", "float", "high"},
+		},
+		{
+			name:       "word count",
+			sortFields: []string{"WordCount:desc"},
+			want:       []string{"float", "low", "high", "unscored"},
+		},
+		{
+			// Recorded reading times and ones estimated from word counts
+			// are whole minutes, as displayed.
+			name:       "reading time",
+			sortFields: []string{"ReadingTime"},
+			want:       []string{"low", "unscored", "high", "float"},
+		},
+		{
+			name:       "domain ignores www",
+			sortFields: []string{"Domain"},
+			want:       []string{"high", "low", "unscored", "float"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := articles()
+			if err := SortArticles(got, tt.sortFields); err != nil {
+				t.Fatalf("SortArticles() returned error: %v", err)
+			}
+
+			for i, article := range got {
+				if article.Title != tt.want[i] {
+					t.Errorf(
+						"SortArticles() at index %d: got %s, want %s",
+						i,
+						article.Title,
+						tt.want[i],
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestSortArticles_UndatedLast(t *testing.T) {
+	now := time.Now()
+	articles := []*Article{
+		{Title: "undated"},
+		{Title: "new", PublishedAt: now},
+		{Title: "old", PublishedAt: now.Add(-time.Hour)},
+	}
+
+	if err := SortArticles(articles, []string{"PublishedAt:desc"}); err != nil {
+		t.Fatalf("SortArticles() returned error: %v", err)
+	}
+
+	want := []string{"new", "old", "undated"}
+	for i, article := range articles {
+		if article.Title != want[i] {
+			t.Errorf("SortArticles() at index %d: got %s, want %s", i, article.Title, want[i])
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/bits"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MetadataCanonicalURL is the metadata key holding an article's canonical URL,
+// as declared by the page itself.
+const MetadataCanonicalURL = "canonical_url"
+
+// MetadataAlsoSeenOn is the metadata key listing where duplicates of an
+// article were also seen.
+const MetadataAlsoSeenOn = "also_seen_on"
+
+// minSimHashWords is the minimum number of words an article needs for its
+// content to be compared. Shorter texts produce unreliable fingerprints.
+const minSimHashWords = 50
+
+// trackingParams are query parameters that only exist for analytics and never
+// change what a URL points to.
+var trackingParams = []string{
+	"fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid", "mc_cid", "mc_eid",
+	"_hsenc", "_hsmi", "mkt_tok", "ref", "ref_src", "ref_url", "cmpid", "s_cid",
+}
+
+// DedupOptions controls how near-duplicate articles are detected.
+type DedupOptions struct {
+	// CanonicalURLs compares canonicalized URLs rather than exact URLs
+	CanonicalURLs bool
+
+	// Similarity is the minimum content similarity, between 0 and 1, for two
+	// articles to be considered duplicates. 0 disables content comparison.
+	Similarity float64
+}
+
+// Validate checks the options for invalid values.
+func (o DedupOptions) Validate() error {
+	if o.Similarity < 0 || o.Similarity > 1 {
+		return fmt.Errorf("dedup_similarity must be between 0 and 1, got %v", o.Similarity)
+	}
+	return nil
+}
+
+// CanonicalURL normalizes a URL so that trivially different URLs for the same
+// page compare equal. The scheme is dropped, the host is lowercased without a
+// leading "www." or default port, tracking parameters and fragments are
+// removed, the remaining query parameters are sorted and a trailing slash is
+// trimmed. Unparseable URLs are returned unchanged.
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || slices.Contains(trackingParams, lower) {
+			query.Del(key)
+		}
+	}
+
+	canonical := host + path
+	if encoded := query.Encode(); encoded != "" {
+		canonical += "?" + encoded
+	}
+
+	return canonical
+}
+
+// RecordCanonicalURL looks for a <link rel="canonical"> in the article's
+// content and stores it in the article's metadata. This must happen before
+// content extraction, which discards the page's head.
+func RecordCanonicalURL(article *Article) {
+	canonical := canonicalLink(article.Content)
+	if canonical == "" {
+		return
+	}
+
+	if base, err := url.Parse(article.URL); err == nil {
+		if ref, err := url.Parse(canonical); err == nil {
+			canonical = base.ResolveReference(ref).String()
+		}
+	}
+
+	if article.Metadata == nil {
+		article.Metadata = make(map[string]any)
+	}
+	article.Metadata[MetadataCanonicalURL] = canonical
+}
+
+func canonicalLink(content string) string {
+	if !strings.Contains(content, "canonical") {
+		return ""
+	}
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+
+	var href string
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if href != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "link" {
+			var rel, h string
+			for _, attr := range n.Attr {
+				switch attr.Key {
+				case "rel":
+					rel = attr.Val
+				case "href":
+					h = attr.Val
+				}
+			}
+			if slices.Contains(strings.Fields(strings.ToLower(rel)), "canonical") {
+				href = strings.TrimSpace(h)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	return href
+}
+
+// articleURLKey returns the canonical form of the article's canonical URL if
+// it declared one, or of its URL otherwise.
+func articleURLKey(article *Article) string {
+	if canonical, ok := article.Metadata[MetadataCanonicalURL].(string); ok && canonical != "" {
+		return CanonicalURL(canonical)
+	}
+	return CanonicalURL(article.URL)
+}
+
+// SimHash computes a 64-bit SimHash fingerprint of text using overlapping
+// three-word shingles. Similar texts have fingerprints that differ in few
+// bits.
+func SimHash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	const shingleSize = 3
+	var weights [64]int
+	shingles := max(len(words)-shingleSize+1, 1)
+	for i := range shingles {
+		end := min(i+shingleSize, len(words))
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		sum := h.Sum64()
+
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+
+	return fingerprint
+}
+
+// SimHashSimilarity returns the similarity of two SimHash fingerprints, from 0
+// (every bit differs) to 1 (identical).
+func SimHashSimilarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
+
+// DeduplicateArticles merges articles that point to the same canonical URL or
+// have near-identical content. Of each group of duplicates, the article with
+// the highest "score" metadata is kept (falling back to the longest content,
+// then the first seen), in the position of the group's first article. The
+// kept article's metadata lists where the others were seen, and it gains
+// their tags.
+func DeduplicateArticles(articles []*Article, opts DedupOptions) ([]*Article, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(articles) <= 1 || (!opts.CanonicalURLs && opts.Similarity == 0) {
+		return articles, nil
+	}
+
+	parent := make([]int, len(articles))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		if ri, rj := find(i), find(j); ri != rj {
+			parent[max(ri, rj)] = min(ri, rj)
+		}
+	}
+
+	if opts.CanonicalURLs {
+		seen := make(map[string]int)
+		for i, article := range articles {
+			key := articleURLKey(article)
+			if key == "" {
+				continue
+			}
+			if j, ok := seen[key]; ok {
+				union(i, j)
+			} else {
+				seen[key] = i
+			}
+		}
+	}
+
+	wordCounts := make([]int, len(articles))
+	fingerprints := make([]uint64, len(articles))
+	for i, article := range articles {
+		text := PlainText(article.Content)
+		wordCounts[i] = CountWords(text)
+		if opts.Similarity > 0 && wordCounts[i] >= minSimHashWords {
+			fingerprints[i] = SimHash(text)
+		}
+	}
+
+	if opts.Similarity > 0 {
+		for i := range articles {
+			if wordCounts[i] < minSimHashWords {
+				continue
+			}
+			for j := i + 1; j < len(articles); j++ {
+				if wordCounts[j] < minSimHashWords {
+					continue
+				}
+				if SimHashSimilarity(fingerprints[i], fingerprints[j]) >= opts.Similarity {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range articles {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	deduped := make([]*Article, 0, len(groups))
+	for i := range articles {
+		group, ok := groups[i]
+		if !ok {
+			continue
+		}
+
+		best := group[0]
+		for _, j := range group[1:] {
+			if betterCopy(articles[j], wordCounts[j], articles[best], wordCounts[best]) {
+				best = j
+			}
+		}
+
+		kept := articles[best]
+		for _, j := range group {
+			if j != best {
+				mergeDuplicate(kept, articles[j])
+			}
+		}
+
+		if len(group) > 1 {
+			log.Printf("Merged %d copies of %q", len(group), kept.Title)
+		}
+		deduped = append(deduped, kept)
+	}
+
+	return deduped, nil
+}
+
+// betterCopy reports whether a is a better copy of an article than b.
+func betterCopy(a *Article, aWords int, b *Article, bWords int) bool {
+	scoreA, okA := numericMetadata(a, "score")
+	scoreB, okB := numericMetadata(b, "score")
+	switch {
+	case okA && !okB:
+		return true
+	case okA && okB && scoreA != scoreB:
+		return scoreA > scoreB
+	case !okA && okB:
+		return false
+	}
+
+	return aWords > bWords
+}
+
+// mergeDuplicate records a duplicate on the article that is being kept.
+func mergeDuplicate(kept, duplicate *Article) {
+	if kept.Metadata == nil {
+		kept.Metadata = make(map[string]any)
+	}
+
+	alsoSeenOn, _ := kept.Metadata[MetadataAlsoSeenOn].([]string)
+	entry := duplicate.SourceName
+	if duplicate.URL != "" {
+		entry += " (" + duplicate.URL + ")"
+	}
+	if !slices.Contains(alsoSeenOn, entry) {
+		alsoSeenOn = append(alsoSeenOn, entry)
+	}
+	if others, ok := duplicate.Metadata[MetadataAlsoSeenOn].([]string); ok {
+		for _, other := range others {
+			if !slices.Contains(alsoSeenOn, other) {
+				alsoSeenOn = append(alsoSeenOn, other)
+			}
+		}
+	}
+	kept.Metadata[MetadataAlsoSeenOn] = alsoSeenOn
+
+	for _, tag := range duplicate.Tags {
+		if !slices.Contains(kept.Tags, tag) {
+			kept.Tags = append(kept.Tags, tag)
+		}
+	}
+}
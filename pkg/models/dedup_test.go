@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{a: "https://example.com/post", b: "http://www.example.com/post/", same: true},
+		{a: "https://EXAMPLE.com:443/post", b: "https://example.com/post", same: true},
+		{
+			a:    "https://example.com/post?utm_source=hn&utm_medium=social&id=1",
+			b:    "https://example.com/post?id=1&fbclid=abc",
+			same: true,
+		},
+		{a: "https://example.com/post#comments", b: "https://example.com/post", same: true},
+		{a: "https://example.com/post?b=2&a=1", b: "https://example.com/post?a=1&b=2", same: true},
+		{a: "https://example.com/post?id=1", b: "https://example.com/post?id=2", same: false},
+		{a: "https://example.com/Post", b: "https://example.com/post", same: false},
+		{a: "https://example.com:8080/post", b: "https://example.com/post", same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, b := CanonicalURL(tt.a), CanonicalURL(tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("CanonicalURL(%q) = %q, CanonicalURL(%q) = %q, want same = %v",
+					tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+}
+
+func TestRecordCanonicalURL(t *testing.T) {
+	article := &Article{
+		URL:     "https://mirror.example.com/story?utm_source=feed",
+		Content: `<html><head><link rel="canonical" href="/original"></head><body></body></html>`,
+	}
+
+	RecordCanonicalURL(article)
+
+	if got := article.Metadata[MetadataCanonicalURL]; got != "https://mirror.example.com/original" {
+		t.Errorf("canonical URL = %v, want resolved link href", got)
+	}
+}
+
+func TestSimHashSimilarity(t *testing.T) {
+	text := longText("the quick brown fox jumps over the lazy dog", 10)
+	edited := strings.Replace(text, "lazy dog", "sleepy cat", 1)
+	other := longText("completely unrelated content about compilers and parsers", 10)
+
+	if sim := SimHashSimilarity(SimHash(text), SimHash(text)); sim != 1 {
+		t.Errorf("identical texts should have similarity 1, got %v", sim)
+	}
+	if sim := SimHashSimilarity(SimHash(text), SimHash(edited)); sim < 0.9 {
+		t.Errorf("near-identical texts should be similar, got %v", sim)
+	}
+	if sim := SimHashSimilarity(SimHash(text), SimHash(other)); sim >= 0.9 {
+		t.Errorf("unrelated texts should not be similar, got %v", sim)
+	}
+}
+
+func TestDeduplicateArticles(t *testing.T) {
+	story := "<p>" + longText("the city council voted to approve the new transit plan", 8) + "</p>"
+	syndicated := strings.Replace(story, "new transit plan", "new transit proposal", 1)
+	unrelated := "<p>" + longText("a review of the latest laptop and its battery life", 8) + "</p>"
+
+	newArticles := func() []*Article {
+		return []*Article{
+			{
+				Title:      "Transit plan approved",
+				URL:        "https://news.example.com/transit?utm_source=rss",
+				Content:    story,
+				SourceName: "rss",
+				Tags:       []string{"city"},
+				Metadata:   map[string]any{},
+			},
+			{
+				Title:      "Laptop review",
+				URL:        "https://reviews.example.com/laptop",
+				Content:    unrelated,
+				SourceName: "rss",
+				Metadata:   map[string]any{},
+			},
+			{
+				Title:      "Transit plan approved (HN)",
+				URL:        "https://www.news.example.com/transit/",
+				SourceName: "hackernews",
+				Metadata:   map[string]any{"score": 120},
+			},
+			{
+				Title:      "Council approves transit",
+				URL:        "https://wire.example.org/council-transit",
+				Content:    syndicated,
+				SourceName: "wire",
+				Tags:       []string{"transit"},
+				Metadata:   map[string]any{},
+			},
+		}
+	}
+
+	t.Run("canonical URLs only", func(t *testing.T) {
+		got, err := DeduplicateArticles(newArticles(), DedupOptions{CanonicalURLs: true})
+		if err != nil {
+			t.Fatalf("DeduplicateArticles() returned error: %v", err)
+		}
+
+		want := []string{"Transit plan approved (HN)", "Laptop review", "Council approves transit"}
+		assertTitles(t, got, want)
+
+		alsoSeenOn := got[0].Metadata[MetadataAlsoSeenOn].([]string)
+		if len(alsoSeenOn) != 1 || !strings.HasPrefix(alsoSeenOn[0], "rss (") {
+			t.Errorf("also_seen_on = %v, want the RSS copy", alsoSeenOn)
+		}
+	})
+
+	t.Run("canonical URLs and content similarity", func(t *testing.T) {
+		got, err := DeduplicateArticles(
+			newArticles(),
+			DedupOptions{CanonicalURLs: true, Similarity: 0.85},
+		)
+		if err != nil {
+			t.Fatalf("DeduplicateArticles() returned error: %v", err)
+		}
+
+		assertTitles(t, got, []string{"Transit plan approved (HN)", "Laptop review"})
+
+		alsoSeenOn := got[0].Metadata[MetadataAlsoSeenOn].([]string)
+		if len(alsoSeenOn) != 2 {
+			t.Errorf("also_seen_on = %v, want both other copies", alsoSeenOn)
+		}
+		if len(got[0].Tags) != 2 {
+			t.Errorf("Tags = %v, want tags merged from duplicates", got[0].Tags)
+		}
+	})
+
+	t.Run("invalid similarity", func(t *testing.T) {
+		if _, err := DeduplicateArticles(newArticles(), DedupOptions{Similarity: 2}); err == nil {
+			t.Error("DeduplicateArticles() expected error, got nil")
+		}
+	})
+}
+
+func longText(sentence string, repeats int) string {
+	parts := make([]string, repeats)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s in paragraph %d.", sentence, i)
+	}
+	return strings.Join(parts, " ")
+}
+
+func assertTitles(t *testing.T, got []*Article, want []string) {
+	t.Helper()
+
+	titles := make([]string, len(got))
+	for i, article := range got {
+		titles[i] = article.Title
+	}
+
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("got articles %v, want %v", titles, want)
+	}
+}
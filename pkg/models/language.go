@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// ValidateLanguages checks that each entry of a list of allowed languages
+// looks like a language code, e.g. "en" or "pt-BR".
+func ValidateLanguages(languages []string) error {
+	for _, lang := range languages {
+		primary := PrimaryLanguage(lang)
+		letters := strings.Trim(primary, "abcdefghijklmnopqrstuvwxyz") == ""
+		if len(primary) < 2 || len(primary) > 3 || !letters {
+			return fmt.Errorf("invalid language code %q", lang)
+		}
+	}
+
+	return nil
+}
+
+// PrimaryLanguage returns the lowercase primary subtag of a language tag, so
+// that "en-GB", "en_US" and "EN" all become "en".
+func PrimaryLanguage(lang string) string {
+	primary, _, _ := strings.Cut(strings.TrimSpace(lang), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	return strings.ToLower(primary)
+}
+
+// Language returns the primary language of an article, as recorded in its
+// metadata, or an empty string if it isn't known.
+func Language(article *Article) string {
+	lang, _ := article.Metadata[MetadataLanguage].(string)
+	return PrimaryLanguage(lang)
+}
+
+// FilterArticlesByLanguage returns the articles written in one of the allowed
+// languages. Languages are compared by their primary subtag, and articles
+// whose language isn't known are kept. An empty list allows every language.
+func FilterArticlesByLanguage(articles []*Article, allowed []string) []*Article {
+	if len(allowed) == 0 {
+		return articles
+	}
+
+	primaries := make([]string, len(allowed))
+	for i, lang := range allowed {
+		primaries[i] = PrimaryLanguage(lang)
+	}
+
+	filtered := make([]*Article, 0, len(articles))
+	for _, article := range articles {
+		lang := Language(article)
+		if lang != "" && !slices.Contains(primaries, lang) {
+			log.Printf("Filtered out %q: language %q is not allowed", article.Title, lang)
+			continue
+		}
+		filtered = append(filtered, article)
+	}
+
+	return filtered
+}
@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestFilterArticlesByLanguage(t *testing.T) {
+	articles := []*Article{
+		{Title: "English", Metadata: map[string]any{MetadataLanguage: "en"}},
+		{Title: "British", Metadata: map[string]any{MetadataLanguage: "en-GB"}},
+		{Title: "German", Metadata: map[string]any{MetadataLanguage: "de"}},
+		{Title: "Unknown"},
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{name: "no restriction", want: []string{"English", "British", "German", "Unknown"}},
+		{
+			name:    "primary subtags",
+			allowed: []string{"EN_us"},
+			want:    []string{"English", "British", "Unknown"},
+		},
+		{
+			name:    "several languages",
+			allowed: []string{"de", "fr"},
+			want:    []string{"German", "Unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertTitles(t, FilterArticlesByLanguage(articles, tt.allowed), tt.want)
+		})
+	}
+}
+
+func TestValidateLanguages(t *testing.T) {
+	tests := []struct {
+		languages []string
+		wantErr   bool
+	}{
+		{languages: nil},
+		{languages: []string{"en", "pt-BR", "fil"}},
+		{languages: []string{"english"}, wantErr: true},
+		{languages: []string{""}, wantErr: true},
+		{languages: []string{"e1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateLanguages(tt.languages)
+		if (err != nil) != tt.wantErr {
+			t.Errorf(
+				"ValidateLanguages(%q) error = %v, wantErr %v",
+				tt.languages,
+				err,
+				tt.wantErr,
+			)
+		}
+	}
+}
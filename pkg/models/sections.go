@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Article properties a digest can be grouped by.
+const (
+	GroupBySourceName = "SourceName"
+	GroupByTags       = "Tags"
+	GroupByDomain     = "Domain"
+)
+
+// untaggedSectionTitle is the title of the section holding articles without
+// tags when grouping by tag.
+const untaggedSectionTitle = "Untagged"
+
+// Section is a named group of articles within a digest.
+type Section struct {
+	// Title of the section
+	Title string
+
+	// Articles in this section
+	Articles []*Article
+}
+
+// ValidateGroupBy checks that field is a property a digest can be grouped by.
+// An empty field means no grouping.
+func ValidateGroupBy(field string) error {
+	switch field {
+	case "", GroupBySourceName, GroupByTags, GroupByDomain:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"invalid group_by %q: must be one of %q, %q or %q",
+		field,
+		GroupBySourceName,
+		GroupByTags,
+		GroupByDomain,
+	)
+}
+
+// GroupBy splits the digest's articles into sections by the given property.
+// Sections are ordered by their first article, and articles keep their
+// relative order within each section, so sorting before grouping controls
+// both. Articles is reordered to match the sections.
+//
+// When grouping by tags, an article with several tags is placed in the section
+// of whichever of its tags is most common in the digest, and articles without
+// tags go in a final "Untagged" section. Likewise, articles without a source
+// name or domain go in a final catch-all section.
+func (d *Digest) GroupBy(field string) error {
+	if err := ValidateGroupBy(field); err != nil {
+		return err
+	}
+
+	if field == "" {
+		d.Sections = nil
+		return nil
+	}
+
+	var keyOf func(*Article) string
+	switch field {
+	case GroupBySourceName:
+		keyOf = func(a *Article) string { return a.SourceName }
+	case GroupByDomain:
+		keyOf = func(a *Article) string { return Domain(a.URL) }
+	case GroupByTags:
+		keyOf = tagGroupKey(d.Articles)
+	}
+
+	sections := make([]*Section, 0)
+	byTitle := make(map[string]*Section)
+	var other *Section
+	for _, article := range d.Articles {
+		key := keyOf(article)
+		if key == "" {
+			if other == nil {
+				other = &Section{Title: emptyGroupTitle(field)}
+			}
+			other.Articles = append(other.Articles, article)
+			continue
+		}
+
+		section, ok := byTitle[key]
+		if !ok {
+			section = &Section{Title: key}
+			byTitle[key] = section
+			sections = append(sections, section)
+		}
+		section.Articles = append(section.Articles, article)
+	}
+
+	if other != nil {
+		sections = append(sections, other)
+	}
+
+	articles := make([]*Article, 0, len(d.Articles))
+	for _, section := range sections {
+		articles = append(articles, section.Articles...)
+	}
+
+	d.Sections = sections
+	d.Articles = articles
+
+	return nil
+}
+
+func emptyGroupTitle(field string) string {
+	switch field {
+	case GroupByTags:
+		return untaggedSectionTitle
+	case GroupByDomain:
+		return "Unknown domain"
+	}
+	return "Other"
+}
+
+// tagGroupKey returns a function picking the section for an article when
+// grouping by tags: the article's tag that is most common across all articles,
+// preferring the article's earlier tags on ties.
+func tagGroupKey(articles []*Article) func(*Article) string {
+	counts := make(map[string]int)
+	for _, article := range articles {
+		seen := make([]string, 0, len(article.Tags))
+		for _, tag := range article.Tags {
+			if tag == "" || slices.Contains(seen, tag) {
+				continue
+			}
+			seen = append(seen, tag)
+			counts[tag]++
+		}
+	}
+
+	return func(article *Article) string {
+		best := ""
+		for _, tag := range article.Tags {
+			if tag != "" && counts[tag] > counts[best] {
+				best = tag
+			}
+		}
+		return best
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDigestGroupBy(t *testing.T) {
+	newDigest := func() *Digest {
+		return &Digest{
+			Articles: []*Article{
+				{
+					Title:      "Go release",
+					URL:        "https://go.dev/blog/go1.24",
+					SourceName: "Go Blog",
+					Tags:       []string{"go", "release"},
+				},
+				{
+					Title:      "Rust release",
+					URL:        "https://blog.rust-lang.org/1.85",
+					SourceName: "HN",
+					Tags:       []string{"rust", "release"},
+				},
+				{
+					Title:      "Untitled musing",
+					URL:        "not a url",
+					SourceName: "",
+				},
+				{
+					Title:      "Go generics",
+					URL:        "https://www.go.dev/blog/generics",
+					SourceName: "HN",
+					Tags:       []string{"go"},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		sections []string
+		articles []string
+	}{
+		{
+			name:     "no grouping",
+			field:    "",
+			articles: []string{"Go release", "Rust release", "Untitled musing", "Go generics"},
+		},
+		{
+			name:     "source name",
+			field:    GroupBySourceName,
+			sections: []string{"Go Blog (1)", "HN (2)", "Other (1)"},
+			articles: []string{"Go release", "Rust release", "Go generics", "Untitled musing"},
+		},
+		{
+			name:     "domain",
+			field:    GroupByDomain,
+			sections: []string{"go.dev (2)", "blog.rust-lang.org (1)", "Unknown domain (1)"},
+			articles: []string{"Go release", "Go generics", "Rust release", "Untitled musing"},
+		},
+		{
+			name:     "most common tag",
+			field:    GroupByTags,
+			sections: []string{"go (2)", "release (1)", "Untagged (1)"},
+			articles: []string{"Go release", "Go generics", "Rust release", "Untitled musing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := newDigest()
+			if err := digest.GroupBy(tt.field); err != nil {
+				t.Fatalf("GroupBy() returned error: %v", err)
+			}
+
+			sections := make([]string, len(digest.Sections))
+			for i, section := range digest.Sections {
+				sections[i] = fmt.Sprintf("%s (%d)", section.Title, len(section.Articles))
+			}
+			if strings.Join(sections, "|") != strings.Join(tt.sections, "|") {
+				t.Errorf("sections = %v, want %v", sections, tt.sections)
+			}
+
+			assertTitles(t, digest.Articles, tt.articles)
+		})
+	}
+
+	if err := newDigest().GroupBy("Author"); err == nil {
+		t.Error("GroupBy() expected error for unsupported field, got nil")
+	}
+}
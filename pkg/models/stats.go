@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Well-known metadata keys holding an article's reading statistics, as
+// recorded by the stats processor.
+const (
+	// MetadataWordCount is the number of words in the article's content
+	MetadataWordCount = "word_count"
+
+	// MetadataReadingTime is the estimated reading time in whole minutes
+	MetadataReadingTime = "reading_time"
+
+	// MetadataImageCount is the number of images in the article's content
+	MetadataImageCount = "image_count"
+
+	// MetadataLanguage is the ISO 639-1 code of the article's language
+	MetadataLanguage = "language"
+)
+
+// DefaultReadingWPM is the reading speed, in words per minute, used to
+// estimate reading time.
+const DefaultReadingWPM = 200
+
+// WordCount returns the number of words in the article's content, as recorded
+// in its metadata if available and counted from its content otherwise.
+func WordCount(article *Article) int {
+	if words, ok := numericMetadata(article, MetadataWordCount); ok {
+		return int(words)
+	}
+
+	return CountWords(PlainText(article.Content))
+}
+
+// ReadingTime returns the article's estimated reading time in whole minutes,
+// as recorded in its metadata if available and estimated from its word count
+// at DefaultReadingWPM otherwise.
+func ReadingTime(article *Article) int {
+	if minutes, ok := numericMetadata(article, MetadataReadingTime); ok {
+		return int(minutes)
+	}
+
+	return ReadingMinutes(WordCount(article), DefaultReadingWPM)
+}
+
+// HasReadingTime reports whether the article's reading time was recorded in
+// its metadata.
+func HasReadingTime(article *Article) bool {
+	_, ok := numericMetadata(article, MetadataReadingTime)
+	return ok
+}
+
+// ReadingMinutes estimates how many minutes it takes to read the given number
+// of words at wpm words per minute, rounded up. Any text takes at least a
+// minute to read.
+func ReadingMinutes(words, wpm int) int {
+	if words <= 0 {
+		return 0
+	}
+	if wpm <= 0 {
+		wpm = DefaultReadingWPM
+	}
+
+	return int(math.Ceil(float64(words) / float64(wpm)))
+}
+
+// FormatReadingTime formats a reading time in minutes for display, e.g.
+// "7 min read".
+func FormatReadingTime(minutes int) string {
+	return fmt.Sprintf("%d min read", max(minutes, 1))
+}
+
+// CountImages returns the number of <img> elements in an HTML fragment.
+func CountImages(htmlContent string) int {
+	if !strings.Contains(htmlContent, "<img") {
+		return 0
+	}
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			count++
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	return count
+}
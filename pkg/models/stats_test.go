@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		words, wpm, want int
+	}{
+		{words: 0, wpm: 200, want: 0},
+		{words: 1, wpm: 200, want: 1},
+		{words: 200, wpm: 200, want: 1},
+		{words: 1350, wpm: 200, want: 7},
+		{words: 1350, wpm: 0, want: 7},
+		{words: 1350, wpm: 300, want: 5},
+	}
+
+	for _, tt := range tests {
+		if got := ReadingMinutes(tt.words, tt.wpm); got != tt.want {
+			t.Errorf("ReadingMinutes(%d, %d) = %d, want %d", tt.words, tt.wpm, got, tt.want)
+		}
+	}
+}
+
+func TestArticleStats(t *testing.T) {
+	article := &Article{
+		Content: `<p>Four words of text.</p><img src="a.png"><figure><img src="b.png"></figure>`,
+	}
+
+	if got := WordCount(article); got != 4 {
+		t.Errorf("WordCount() = %d, want 4", got)
+	}
+	if got := ReadingTime(article); got != 1 {
+		t.Errorf("ReadingTime() = %d, want 1", got)
+	}
+	if got := CountImages(article.Content); got != 2 {
+		t.Errorf("CountImages() = %d, want 2", got)
+	}
+	if HasReadingTime(article) {
+		t.Error("HasReadingTime() = true for an article without stats")
+	}
+
+	article.Metadata = map[string]any{MetadataWordCount: 1400, MetadataReadingTime: 7}
+	if got := WordCount(article); got != 1400 {
+		t.Errorf("WordCount() = %d, want recorded 1400", got)
+	}
+	if got := FormatReadingTime(ReadingTime(article)); got != "7 min read" {
+		t.Errorf("FormatReadingTime() = %q, want %q", got, "7 min read")
+	}
+}
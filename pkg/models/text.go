@@ -0,0 +1,130 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// PlainText extracts the visible text from an HTML fragment, dropping tags,
+// attributes, scripts and styles. Block-level elements are separated by
+// newlines so words on either side of them don't run together.
+func PlainText(htmlContent string) string {
+	if htmlContent == "" {
+		return ""
+	}
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return htmlContent
+	}
+
+	var sb strings.Builder
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+
+		if n.Type == html.ElementNode && isBlockElement(n.Data) {
+			sb.WriteString("\n")
+		}
+	}
+
+	traverse(doc)
+
+	return strings.TrimSpace(sb.String())
+}
+
+// Paragraphs extracts the visible text of each block-level element in an
+// HTML fragment, such as paragraphs, headings and list items, with runs of
+// whitespace collapsed to single spaces. Empty blocks are skipped.
+func Paragraphs(htmlContent string) []string {
+	if htmlContent == "" {
+		return nil
+	}
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil
+	}
+
+	paragraphs := make([]string, 0)
+	var sb strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(sb.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		sb.Reset()
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+		}
+
+		block := n.Type == html.ElementNode && isBlockElement(n.Data)
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+		if block {
+			flush()
+		}
+	}
+
+	traverse(doc)
+	flush()
+
+	return paragraphs
+}
+
+// CountWords returns the number of whitespace-separated words in text that
+// contain at least one letter or digit.
+func CountWords(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, isWordRune) >= 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isBlockElement(tag string) bool {
+	switch tag {
+	case "p", "div", "br", "li", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6",
+		"blockquote", "pre", "table", "tr", "td", "th", "section", "article",
+		"header", "footer", "figure", "figcaption", "hr", "dd", "dt", "dl":
+		return true
+	}
+
+	return false
+}
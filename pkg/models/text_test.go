@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    "",
+		},
+		{
+			name:    "strips tags and attributes",
+			content: `<p>Hello <a href="https://example.com/said">world</a></p>`,
+			want:    "Hello world",
+		},
+		{
+			name:    "drops scripts and styles",
+			content: `<style>p { color: red }</style><p>Text</p><script>alert(1)</script>`,
+			want:    "Text",
+		},
+		{
+			name:    "separates block elements",
+			content: `<p>First</p><p>Second</p>`,
+			want:    "First\nSecond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.content); got != tt.want {
+				t.Errorf("PlainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "one two three", want: 3},
+		{text: "  spaced\tout\nwords  ", want: 3},
+		{text: "dashes - aren't — words", want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := CountWords(tt.text); got != tt.want {
+			t.Errorf("CountWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParagraphs(t *testing.T) {
+	content := `<h1>Title</h1>
+		<p>First   paragraph,
+		wrapped in the <em>source</em>.</p>
+		<ul><li>One</li><li>Two</li></ul>
+		<script>ignored()</script>
+		<p></p>`
+
+	want := []string{"Title", "First paragraph, wrapped in the source.", "One", "Two"}
+	got := Paragraphs(content)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Paragraphs() = %q, want %q", got, want)
+	}
+}
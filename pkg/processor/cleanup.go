@@ -0,0 +1,177 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// CleanupProcessor removes unwanted parts of articles, such as newsletter
+// signup boxes and share bars, using CSS selectors. It is meant to be
+// configured per source, for sites that readability doesn't handle well.
+type CleanupProcessor struct{}
+
+// NewCleanupProcessor creates a new instance of CleanupProcessor.
+func NewCleanupProcessor() *CleanupProcessor {
+	return &CleanupProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *CleanupProcessor) Name() string {
+	return "cleanup"
+}
+
+// cleanupOptions holds the additional options of the cleanup processor.
+type cleanupOptions struct {
+	KeepSelector    string              `option:"keep_selector"`
+	RemoveSelectors []string            `option:"remove_selectors"`
+	StripAttributes []string            `option:"strip_attributes"`
+	Replacements    []map[string]string `option:"replacements"`
+
+	keep         cascadia.Selector
+	remove       []cascadia.Selector
+	replacements []textReplacement
+}
+
+// check compiles the selectors and replacement patterns.
+func (o *cleanupOptions) check() error {
+	if o.KeepSelector != "" {
+		sel, err := cascadia.Compile(o.KeepSelector)
+		if err != nil {
+			return fmt.Errorf("invalid keep_selector %q: %w", o.KeepSelector, err)
+		}
+		o.keep = sel
+	}
+
+	for _, selector := range o.RemoveSelectors {
+		sel, err := cascadia.Compile(selector)
+		if err != nil {
+			return fmt.Errorf("invalid remove_selectors entry %q: %w", selector, err)
+		}
+		o.remove = append(o.remove, sel)
+	}
+
+	for _, table := range o.Replacements {
+		pattern := table["pattern"]
+		if pattern == "" {
+			return errors.New("each replacement needs a pattern")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid replacement pattern %q: %w", pattern, err)
+		}
+		o.replacements = append(
+			o.replacements,
+			textReplacement{pattern: re, replacement: table["replacement"]},
+		)
+	}
+
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *CleanupProcessor) NewOptions() any {
+	return &cleanupOptions{}
+}
+
+// textReplacement replaces matches of a pattern in an article's text.
+type textReplacement struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Process cleans up the article's content. It supports these additional
+// options, applied in this order:
+//
+//   - "keep_selector": replace the content with the elements matching this
+//     selector, e.g. "article .post-body", instead of running readability
+//   - "remove_selectors": remove the elements matching any of these selectors
+//   - "strip_attributes": remove these attributes from every element
+//   - "replacements": a list of tables with a "pattern" regular expression and
+//     its "replacement", applied to the text of the content but not its markup
+//
+// If the keep selector doesn't match anything, the content is left unchanged
+// and ErrContentProcessingFailed is returned.
+func (p *CleanupProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	if article.Content == "" {
+		return nil
+	}
+
+	cleanup, err := processorOptions[cleanupOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(article.Content))
+	if err != nil {
+		return err
+	}
+
+	original := article.Content
+	if cleanup.keep != nil {
+		// Matches nested in other matches are kept with their ancestor.
+		kept := doc.FindMatcher(cleanup.keep)
+		kept = kept.FilterFunction(func(_ int, s *goquery.Selection) bool {
+			return s.ParentsMatcher(cleanup.keep).Length() == 0
+		})
+		if kept.Length() == 0 {
+			return fmt.Errorf("%w: keep_selector matched nothing", ErrContentProcessingFailed)
+		}
+
+		kept.Remove()
+		doc.Find("head").Empty()
+		body := doc.Find("body")
+		body.Empty()
+		body.AppendSelection(kept)
+		// The result is a fragment, whatever the original content was.
+		original = ""
+	}
+
+	for _, sel := range cleanup.remove {
+		doc.FindMatcher(sel).Remove()
+	}
+
+	if len(cleanup.StripAttributes) > 0 {
+		doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+			for _, attr := range cleanup.StripAttributes {
+				s.RemoveAttr(attr)
+			}
+		})
+	}
+
+	if len(cleanup.replacements) > 0 {
+		replaceText(doc.Get(0), cleanup.replacements)
+	}
+
+	article.Content = renderContent(doc.Get(0), original)
+	return nil
+}
+
+// replaceText applies replacements to every text node under n, leaving
+// scripts and styles alone.
+func replaceText(n *html.Node, replacements []textReplacement) {
+	if n.Type == html.TextNode {
+		for _, r := range replacements {
+			n.Data = r.pattern.ReplaceAllString(n.Data, r.replacement)
+		}
+		return
+	}
+	if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		replaceText(c, replacements)
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestCleanupProcessor_Process(t *testing.T) {
+	page := `<html><head><title>Post</title></head><body>
+		<nav>Home | About</nav>
+		<article class="post">
+			<h1 style="color: red">Post</h1>
+			<div class="share-bar">Share on X</div>
+			<p class="lead" data-track="1">Hello, world. Advertisement</p>
+			<aside class="newsletter">Sign up!</aside>
+			<section class="post"><p>Nested</p></section>
+		</article>
+		<div class="related">Related articles</div>
+	</body></html>`
+
+	tests := []struct {
+		name    string
+		content string
+		options map[string]any
+		want    []string
+		notWant []string
+		wantErr error
+	}{
+		{
+			name:    "remove selectors",
+			content: page,
+			options: map[string]any{
+				"remove_selectors": []any{".share-bar", "aside.newsletter, .related"},
+			},
+			want:    []string{"<title>Post</title>", "<nav>", "Hello, world."},
+			notWant: []string{"Share on X", "Sign up!", "Related articles"},
+		},
+		{
+			name:    "keep selector",
+			content: page,
+			options: map[string]any{"keep_selector": "article.post"},
+			want:    []string{`<article class="post">`, "Nested"},
+			notWant: []string{"<html>", "<title>", "<nav>", "Related articles"},
+		},
+		{
+			name:    "strip attributes",
+			content: `<p class="lead" style="color: red" data-track="1">Hi</p>`,
+			options: map[string]any{"strip_attributes": []any{"style", "data-track"}},
+			want:    []string{`<p class="lead">Hi</p>`},
+		},
+		{
+			name:    "text replacements",
+			content: `<p class="Advertisement">Hello, world. Advertisement</p>`,
+			options: map[string]any{
+				"replacements": []map[string]any{
+					{"pattern": `\s*Advertisement$`},
+					{"pattern": `(?i)hello`, "replacement": "Goodbye"},
+				},
+			},
+			want: []string{`<p class="Advertisement">Goodbye, world.</p>`},
+		},
+		{
+			name:    "keep selector without matches",
+			content: page,
+			options: map[string]any{"keep_selector": ".missing"},
+			wantErr: ErrContentProcessingFailed,
+		},
+		{
+			name:    "invalid selector",
+			content: page,
+			options: map[string]any{"remove_selectors": []any{"div["}},
+			wantErr: errAny,
+		},
+		{
+			name:    "invalid pattern",
+			content: page,
+			options: map[string]any{"replacements": []any{map[string]any{"pattern": "("}}},
+			wantErr: errAny,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{URL: "https://example.com/post", Content: tt.content}
+			opts := DefaultOptions()
+			opts.AdditionalOptions = tt.options
+
+			err := NewCleanupProcessor().Process(article, &opts)
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+				}
+				if article.Content != tt.content {
+					t.Errorf("content should be unchanged on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(article.Content, want) {
+					t.Errorf("content should contain %q, got:\n%s", want, article.Content)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(article.Content, notWant) {
+					t.Errorf("content should not contain %q, got:\n%s", notWant, article.Content)
+				}
+			}
+		})
+	}
+}
+
+// errAny is a sentinel for tests expecting some error.
+var errAny = errors.New("any error")
@@ -0,0 +1,311 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+const (
+	// defaultExecTimeout is how long a command may run per article by
+	// default.
+	defaultExecTimeout = 30 * time.Second
+
+	// execWaitDelay is how long to wait for a killed command's output
+	// pipes to close, e.g. when it left children running.
+	execWaitDelay = time.Second
+
+	// maxExecOutput is how much a command may write to stdout, so a runaway
+	// command can't use up memory.
+	maxExecOutput = 64 << 20
+
+	// maxExecStderr is how much of the end of a command's stderr is kept, to
+	// be logged or included in its error.
+	maxExecStderr = 1000
+)
+
+// execArticle is an article as exchanged with exec commands as JSON.
+type execArticle struct {
+	Title       string         `json:"title"`
+	Author      string         `json:"author"`
+	PublishedAt *time.Time     `json:"published_at"`
+	URL         string         `json:"url"`
+	Content     string         `json:"content"`
+	Summary     string         `json:"summary"`
+	SourceName  string         `json:"source_name"`
+	Tags        []string       `json:"tags"`
+	Metadata    map[string]any `json:"metadata"`
+}
+
+func newExecArticle(article *models.Article) execArticle {
+	e := execArticle{
+		Title:      article.Title,
+		Author:     article.Author,
+		URL:        article.URL,
+		Content:    article.Content,
+		Summary:    article.Summary,
+		SourceName: article.SourceName,
+		Tags:       article.Tags,
+		Metadata:   article.Metadata,
+	}
+	if !article.PublishedAt.IsZero() {
+		publishedAt := article.PublishedAt
+		e.PublishedAt = &publishedAt
+	}
+	return e
+}
+
+func (e execArticle) article() models.Article {
+	article := models.Article{
+		Title:      e.Title,
+		Author:     e.Author,
+		URL:        e.URL,
+		Content:    e.Content,
+		Summary:    e.Summary,
+		SourceName: e.SourceName,
+		Tags:       e.Tags,
+		Metadata:   e.Metadata,
+	}
+	if e.PublishedAt != nil {
+		article.PublishedAt = *e.PublishedAt
+	}
+	return article
+}
+
+// execOptions holds the additional options of the exec processor.
+type execOptions struct {
+	Command    []string          `option:"command"`
+	Timeout    string            `option:"timeout"`
+	Env        map[string]string `option:"env"`
+	WorkingDir string            `option:"working_dir"`
+
+	timeout time.Duration
+	env     []string
+}
+
+// check checks that a command is configured and the timeout is a valid
+// duration.
+func (o *execOptions) check() error {
+	if len(o.Command) == 0 || o.Command[0] == "" {
+		return errors.New("command is required, as a list of a program and its arguments")
+	}
+
+	timeout, err := time.ParseDuration(o.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", o.Timeout, err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %s", o.Timeout)
+	}
+	o.timeout = timeout
+
+	o.env = nil
+	for _, key := range slices.Sorted(maps.Keys(o.Env)) {
+		o.env = append(o.env, key+"="+o.Env[key])
+	}
+
+	return nil
+}
+
+// ExecProcessor processes articles with an external command, so articles can
+// be processed with tools written in any language.
+type ExecProcessor struct{}
+
+// NewExecProcessor creates a new instance of ExecProcessor.
+func NewExecProcessor() *ExecProcessor {
+	return &ExecProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *ExecProcessor) Name() string {
+	return "exec"
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *ExecProcessor) NewOptions() any {
+	return &execOptions{Timeout: defaultExecTimeout.String()}
+}
+
+// ProcessContext runs the "command" additional option, a program and its
+// arguments, writing the article to its stdin as a JSON object and reading
+// the processed article from its stdout in the same form. Fields the command
+// leaves out of its output keep their values, and whole numbers in its
+// metadata become ints. The command runs in the "working_dir" additional
+// option, with the variables in the "env" additional option added to
+// dijester's environment. A relative working_dir is resolved against the
+// directory dijester runs in, and a relative program path against
+// working_dir.
+//
+// The command fails, and its error is handled by the processor's on_error
+// policy, if it exits with a non-zero status, runs longer than the "timeout"
+// additional option (30s by default), or writes anything but an article's
+// JSON to stdout. The end of its stderr is logged, and included in the error
+// if it fails.
+func (p *ExecProcessor) ProcessContext(
+	ctx context.Context,
+	article *models.Article,
+	_ fetcher.Fetcher,
+	opts *Options,
+) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	cfg, err := processorOptions[execOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	input, err := json.Marshal(newExecArticle(article))
+	if err != nil {
+		return fmt.Errorf("encoding article: %w", err)
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{max: maxExecOutput}
+	stderr := &tailBuffer{max: maxExecStderr}
+	cmd := exec.CommandContext(cmdCtx, cfg.Command[0], cfg.Command[1:]...)
+	cmd.Dir = cfg.WorkingDir
+	cmd.Env = append(os.Environ(), cfg.env...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = execWaitDelay
+
+	err = cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		if errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", cfg.timeout)
+		}
+		return fmt.Errorf(
+			"running %s: %w%s",
+			cfg.Command[0],
+			err,
+			stderrDetail(stderr.String()),
+		)
+	}
+
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		log.Printf("%s for %q: %s", cfg.Command[0], article.Title, msg)
+	}
+
+	if stdout.truncated {
+		return fmt.Errorf("%s wrote more than %d bytes to stdout", cfg.Command[0], maxExecOutput)
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return fmt.Errorf("%s wrote no article to stdout", cfg.Command[0])
+	}
+
+	// Decoding into the article's own tags and metadata would change them
+	// even if decoding fails, and merge metadata rather than replace it.
+	output := newExecArticle(article)
+	output.Tags, output.Metadata = nil, nil
+	decoder := json.NewDecoder(stdout)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&output); err != nil {
+		return fmt.Errorf("decoding article from %s: %w", cfg.Command[0], err)
+	}
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return fmt.Errorf("%s wrote more than an article to stdout", cfg.Command[0])
+	}
+	if output.Tags == nil {
+		output.Tags = article.Tags
+	}
+	if output.Metadata == nil {
+		output.Metadata = article.Metadata
+	} else {
+		restoreIntegers(output.Metadata)
+	}
+
+	*article = output.article()
+	return nil
+}
+
+// restoreIntegers converts whole numbers in metadata decoded from JSON, which
+// decodes every number as a float64, back to ints, as processors record
+// counts like word_count as ints.
+func restoreIntegers(metadata map[string]any) {
+	for key, v := range metadata {
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			metadata[key] = int(f)
+		}
+	}
+}
+
+// Process runs the command without a context to cancel it with.
+func (p *ExecProcessor) Process(article *models.Article, opts *Options) error {
+	return p.ProcessContext(context.Background(), article, nil, opts)
+}
+
+// stderrDetail formats a failed command's stderr to be appended to its
+// error.
+func stderrDetail(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	return ": " + stderr
+}
+
+// cappedBuffer is a buffer that keeps at most max bytes, discarding the
+// rest, so a command keeps running to completion rather than blocking on a
+// full pipe.
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// tailBuffer is a buffer that keeps only the last max bytes written to it.
+type tailBuffer struct {
+	data      []byte
+	max       int
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if over := len(b.data) - b.max; over > 0 {
+		b.data = append(b.data[:0], b.data[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the kept bytes, starting with "..." if earlier ones were
+// discarded.
+func (b *tailBuffer) String() string {
+	if b.truncated {
+		return "..." + string(b.data)
+	}
+	return string(b.data)
+}
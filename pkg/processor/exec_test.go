@@ -0,0 +1,177 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// TestExecHelperProcess is run as the exec processor's command by the tests
+// below, behaving as the mode in DIJESTER_EXEC_HELPER says.
+func TestExecHelperProcess(t *testing.T) {
+	mode := os.Getenv("DIJESTER_EXEC_HELPER")
+	if mode == "" {
+		return
+	}
+
+	var article map[string]any
+	if err := json.NewDecoder(os.Stdin).Decode(&article); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch mode {
+	case "upper":
+		fmt.Fprintln(os.Stderr, "uppercasing")
+		cwd, _ := os.Getwd()
+		json.NewEncoder(os.Stdout).Encode(map[string]any{
+			"title":   strings.ToUpper(article["title"].(string)),
+			"content": article["content"].(string) + "<p>" + os.Getenv("SIGNATURE") + "</p>",
+			"tags":    append(article["tags"].([]any), "processed"),
+			"metadata": map[string]any{
+				"score": article["metadata"].(map[string]any)["score"],
+				"dir":   filepath.Base(cwd),
+			},
+		})
+	case "fail":
+		fmt.Fprintln(os.Stderr, "something broke")
+		os.Exit(2)
+	case "sleep":
+		time.Sleep(10 * time.Second)
+	case "garbage":
+		fmt.Println(`{"title": "x", "unknown": true}`)
+	case "trailing":
+		fmt.Println(`{"title": "x"}`)
+		fmt.Println("debug: done")
+	case "noisy":
+		fmt.Fprint(os.Stderr, strings.Repeat("noise ", 1000)+"the real error")
+		os.Exit(1)
+	case "silent":
+	}
+	os.Exit(0)
+}
+
+func TestExecProcessor_Process(t *testing.T) {
+	dir := t.TempDir()
+	published := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mode    string
+		options map[string]any
+		wantErr string
+	}{
+		{
+			name: "processes the article",
+			mode: "upper",
+			options: map[string]any{
+				"env":         map[string]any{"SIGNATURE": "-- processed"},
+				"working_dir": dir,
+			},
+		},
+		{name: "non-zero exit", mode: "fail", wantErr: "something broke"},
+		{
+			name:    "timeout",
+			mode:    "sleep",
+			options: map[string]any{"timeout": "100ms"},
+			wantErr: "timed out after 100ms",
+		},
+		{name: "invalid output", mode: "garbage", wantErr: `unknown field "unknown"`},
+		{name: "no output", mode: "silent", wantErr: "wrote no article"},
+		{name: "output after the article", mode: "trailing", wantErr: "more than an article"},
+		{name: "long stderr", mode: "noisy", wantErr: "noise the real error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DIJESTER_EXEC_HELPER", tt.mode)
+
+			article := &models.Article{
+				Title:       "Story",
+				URL:         "https://example.com/story",
+				PublishedAt: published,
+				Content:     "<p>Text</p>",
+				Tags:        []string{"go"},
+				Metadata:    map[string]any{"score": 42},
+			}
+			original := *article
+
+			options := map[string]any{
+				"command": []any{os.Args[0], "-test.run=^TestExecHelperProcess$"},
+			}
+			for key, v := range tt.options {
+				options[key] = v
+			}
+			opts, err := DecodeOptions(NewExecProcessor(), map[string]any{
+				"additional_options": options,
+			})
+			if err != nil {
+				t.Fatalf("DecodeOptions() returned error: %v", err)
+			}
+
+			err = NewExecProcessor().Process(article, &opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Process() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(err.Error()) > maxExecStderr+len(os.Args[0])+100 {
+					t.Errorf("Process() error should keep only the end of stderr, got %v", err)
+				}
+				if article.Title != original.Title || !slices.Equal(article.Tags, []string{"go"}) {
+					t.Errorf("article should be unchanged on error, got %+v", article)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			if article.Title != "STORY" {
+				t.Errorf("Title = %q, want %q", article.Title, "STORY")
+			}
+			if article.Content != "<p>Text</p><p>-- processed</p>" {
+				t.Errorf("Content = %q, want the env variable appended", article.Content)
+			}
+			if !slices.Equal(article.Tags, []string{"go", "processed"}) {
+				t.Errorf("Tags = %v", article.Tags)
+			}
+			if article.URL != original.URL || !article.PublishedAt.Equal(published) {
+				t.Errorf("fields left out of the output should be unchanged, got %+v", article)
+			}
+			if article.Metadata["score"] != 42 {
+				t.Errorf("score = %#v, want the int 42", article.Metadata["score"])
+			}
+			if article.Metadata["dir"] != filepath.Base(dir) {
+				t.Errorf("dir = %v, want the working directory %q", article.Metadata["dir"], dir)
+			}
+		})
+	}
+}
+
+func TestExecProcessor_InvalidOptions(t *testing.T) {
+	for _, options := range []map[string]any{
+		{},
+		{"command": []any{}},
+		{"command": []any{"cat"}, "timeout": "soon"},
+		{"command": []any{"cat"}, "env": map[string]any{"N": int64(1)}},
+	} {
+		_, err := DecodeOptions(NewExecProcessor(), map[string]any{"additional_options": options})
+		if err == nil {
+			t.Errorf("DecodeOptions() with %v expected error, got nil", options)
+		}
+	}
+
+	article := &models.Article{Title: "Story"}
+	err := NewExecProcessor().Process(article, &Options{})
+	if err == nil || errors.Is(err, ErrContentProcessingFailed) {
+		t.Errorf("Process() without a command error = %v, want a configuration error", err)
+	}
+}
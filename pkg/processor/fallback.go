@@ -0,0 +1,298 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// MetadataExtractionStrategy is the metadata key recording which strategy
+// of the fallback processor produced an article's content.
+const MetadataExtractionStrategy = "extraction_strategy"
+
+// Strategies of the fallback processor, in their default order.
+const (
+	// StrategyReadability extracts the article from its page
+	StrategyReadability = "readability"
+
+	// StrategyFeed uses the content provided by the article's feed
+	StrategyFeed = "feed"
+
+	// StrategyArchive extracts the article from an archived copy of its page
+	StrategyArchive = "archive"
+
+	// StrategySummary uses the article's summary and a link to read it online
+	StrategySummary = "summary"
+)
+
+// defaultFallbackMinWords is the default number of words content needs for a
+// strategy to succeed.
+const defaultFallbackMinWords = 150
+
+var defaultStrategies = []string{
+	StrategyReadability,
+	StrategyFeed,
+	StrategyArchive,
+	StrategySummary,
+}
+
+// archiveURLData is the data available to archive URL templates.
+type archiveURLData struct {
+	// URL is the article's URL
+	URL string
+
+	// EscapedURL is the article's URL, escaped to be used as a query value
+	EscapedURL string
+
+	// Host is the host of the article's URL
+	Host string
+}
+
+// FallbackProcessor extracts an article's content by trying several
+// strategies in turn until one produces enough text, for pages that
+// readability can't extract much from, such as paywalled or script-heavy
+// pages.
+type FallbackProcessor struct {
+	readability *ReadabilityProcessor
+}
+
+// NewFallbackProcessor creates a new instance of FallbackProcessor.
+func NewFallbackProcessor() *FallbackProcessor {
+	return &FallbackProcessor{readability: NewReadabilityProcessor()}
+}
+
+// Name returns the name of this processor.
+func (p *FallbackProcessor) Name() string {
+	return "fallback"
+}
+
+// fallbackOptions holds the additional options of the fallback processor,
+// including those of the readability processor it extracts pages with.
+type fallbackOptions struct {
+	readabilityOptions
+
+	Strategies []string `option:"strategies"`
+	MinWords   int      `option:"min_words"`
+	ArchiveURL string   `option:"archive_url"`
+
+	archiveURL *template.Template
+}
+
+// check checks the readability options, strategies and word count, and
+// parses the archive URL template.
+func (o *fallbackOptions) check() error {
+	if err := o.readabilityOptions.check(); err != nil {
+		return err
+	}
+
+	for _, strategy := range o.Strategies {
+		if !slices.Contains(defaultStrategies, strategy) {
+			return fmt.Errorf(
+				"unknown strategy %q: must be one of %s",
+				strategy,
+				strings.Join(defaultStrategies, ", "),
+			)
+		}
+	}
+
+	if o.MinWords < 0 {
+		return fmt.Errorf("min_words must not be negative, got %d", o.MinWords)
+	}
+
+	if o.ArchiveURL != "" {
+		var err error
+		o.archiveURL, err = template.New("archive_url").Parse(o.ArchiveURL)
+		if err != nil {
+			return fmt.Errorf("parsing archive_url: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *FallbackProcessor) NewOptions() any {
+	return &fallbackOptions{
+		readabilityOptions: newReadabilityOptions(),
+		Strategies:         defaultStrategies,
+		MinWords:           defaultFallbackMinWords,
+	}
+}
+
+// ProcessContext tries each strategy in the "strategies" additional option in
+// order, by default readability, feed, archive and summary, until one
+// produces at least "min_words" words (150 by default):
+//
+//   - readability extracts the article from its page, fetching the page
+//     unless the content already is one
+//   - feed uses the content provided by the article's feed, if the content
+//     isn't a fetched page
+//   - archive fetches the URL produced by the "archive_url" template, e.g.
+//     "https://archive.example.com/newest/{{.URL}}", and extracts the
+//     article from it
+//   - summary always succeeds, using the article's summary and a link to
+//     read it online
+//
+// The winning strategy is recorded in the article's metadata. If every
+// strategy fails, the content is left unchanged and
+// ErrContentProcessingFailed returned.
+func (p *FallbackProcessor) ProcessContext(
+	ctx context.Context,
+	article *models.Article,
+	fetcher fetcher.Fetcher,
+	opts *Options,
+) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	options, err := processorOptions[fallbackOptions](p, opts)
+	if err != nil {
+		return err
+	}
+	pageOpts := withOptions(opts, &options.readabilityOptions)
+
+	original := article.Content
+	for _, strategy := range options.Strategies {
+		var candidate *models.Article
+		var err error
+		switch strategy {
+		case StrategyReadability:
+			candidate, err = p.extractPage(ctx, article, original, article.URL, fetcher, pageOpts)
+		case StrategyFeed:
+			if isDocument(original) {
+				err = errors.New("the article has no feed content")
+			} else {
+				candidate = copyArticle(article)
+				candidate.Content = original
+			}
+		case StrategyArchive:
+			if options.archiveURL == nil {
+				err = errors.New("no archive_url is configured")
+				break
+			}
+			var target string
+			target, err = executeArchiveURL(options.archiveURL, article.URL)
+			if err == nil {
+				candidate, err = p.extractPage(ctx, article, "", target, fetcher, pageOpts)
+			}
+		case StrategySummary:
+			candidate = copyArticle(article)
+			candidate.Content = summaryContent(article)
+		}
+
+		if err == nil && strategy != StrategySummary {
+			words := models.CountWords(models.PlainText(candidate.Content))
+			if words < options.MinWords {
+				err = fmt.Errorf("only %d words", words)
+			}
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			log.Printf("Strategy %s failed for %q: %v", strategy, article.Title, err)
+			continue
+		}
+
+		*article = *candidate
+		if article.Metadata == nil {
+			article.Metadata = make(map[string]any)
+		}
+		article.Metadata[MetadataExtractionStrategy] = strategy
+		return nil
+	}
+
+	return fmt.Errorf("%w: every strategy failed", ErrContentProcessingFailed)
+}
+
+// Process tries the strategies that don't need to fetch anything.
+func (p *FallbackProcessor) Process(article *models.Article, opts *Options) error {
+	return p.ProcessContext(context.Background(), article, nil, opts)
+}
+
+// extractPage extracts an article with readability from page, or from the
+// page at pageURL if page isn't a whole document.
+func (p *FallbackProcessor) extractPage(
+	ctx context.Context,
+	article *models.Article,
+	page string,
+	pageURL string,
+	fetcher fetcher.Fetcher,
+	opts *Options,
+) (*models.Article, error) {
+	if !isDocument(page) {
+		if fetcher == nil {
+			return nil, errors.New("no fetcher to fetch the page with")
+		}
+		if pageURL == "" {
+			return nil, errors.New("the article has no URL")
+		}
+
+		var err error
+		page, err = fetcher.FetchURLAsString(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidate := copyArticle(article)
+	candidate.Content = page
+	if err := p.readability.Process(candidate, opts); err != nil {
+		return nil, err
+	}
+
+	// Extracting an archived copy mustn't change the article's URL.
+	candidate.URL = article.URL
+	return candidate, nil
+}
+
+// executeArchiveURL fills in an archive URL template for an article's URL.
+func executeArchiveURL(tmpl *template.Template, articleURL string) (string, error) {
+	data := archiveURLData{URL: articleURL, EscapedURL: url.QueryEscape(articleURL)}
+	if u, err := url.Parse(articleURL); err == nil {
+		data.Host = u.Host
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing archive_url: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// summaryContent returns content made of an article's summary, if any, and a
+// link to read the article online. Summaries are often HTML, e.g. feed
+// descriptions, so only the text of each of their paragraphs is kept.
+func summaryContent(article *models.Article) string {
+	var buf strings.Builder
+	for _, paragraph := range models.Paragraphs(article.Summary) {
+		fmt.Fprintf(&buf, "<p>%s</p>\n", html.EscapeString(paragraph))
+	}
+	if article.URL != "" {
+		fmt.Fprintf(&buf, `<p><a href="%s">Read online</a></p>`, html.EscapeString(article.URL))
+	}
+	return buf.String()
+}
+
+// copyArticle returns a copy of an article that can be changed without
+// changing the original, apart from its tags.
+func copyArticle(article *models.Article) *models.Article {
+	candidate := *article
+	if article.Metadata != nil {
+		candidate.Metadata = make(map[string]any, len(article.Metadata))
+		for k, v := range article.Metadata {
+			candidate.Metadata[k] = v
+		}
+	}
+	return &candidate
+}
@@ -0,0 +1,186 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestFallbackProcessor_ProcessContext(t *testing.T) {
+	fullText := strings.Repeat("The whole story goes on at some length. ", 30)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/archive/"):
+			fmt.Fprint(w, fallbackPage("Archived. "+fullText))
+		case r.URL.Path == "/full":
+			fmt.Fprint(w, fallbackPage(fullText))
+		case r.URL.Path == "/paywalled":
+			fmt.Fprint(w, fallbackPage("Subscribe to keep reading."))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		content      string
+		options      map[string]any
+		wantStrategy string
+		want         []string
+		wantErr      error
+	}{
+		{
+			name:         "readability on the fetched page",
+			path:         "/full",
+			content:      "<p>Short teaser.</p>",
+			wantStrategy: StrategyReadability,
+			want:         []string{"The whole story"},
+		},
+		{
+			name:         "feed content",
+			path:         "/paywalled",
+			content:      "<p>" + fullText + "</p>",
+			wantStrategy: StrategyFeed,
+			want:         []string{"The whole story"},
+		},
+		{
+			name:    "archive",
+			path:    "/paywalled",
+			content: "<p>Short teaser.</p>",
+			options: map[string]any{
+				"archive_url": server.URL + "/archive/{{.EscapedURL}}",
+			},
+			wantStrategy: StrategyArchive,
+			want:         []string{"Archived. The whole story"},
+		},
+		{
+			name:         "summary",
+			path:         "/paywalled",
+			content:      "<p>Short teaser.</p>",
+			wantStrategy: StrategySummary,
+			want:         []string{"<p>A summary &amp; more</p>", ">Read online</a>"},
+		},
+		{
+			name:    "every strategy fails",
+			path:    "/paywalled",
+			content: "<p>Short teaser.</p>",
+			options: map[string]any{"strategies": []any{"readability", "feed"}},
+			wantErr: ErrContentProcessingFailed,
+		},
+		{
+			name:    "unknown strategy",
+			path:    "/full",
+			content: "<p>Short teaser.</p>",
+			options: map[string]any{"strategies": []any{"guess"}},
+			wantErr: errAny,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{
+				Title:   "Story",
+				URL:     server.URL + tt.path,
+				Summary: "A summary & more",
+				Content: tt.content,
+			}
+			opts := DefaultOptions()
+			opts.MinContentLength = 0
+			opts.AdditionalOptions = tt.options
+
+			err := NewFallbackProcessor().ProcessContext(
+				context.Background(),
+				article,
+				fetcher.NewHTTPFetcher(),
+				&opts,
+			)
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("ProcessContext() error = %v, want %v", err, tt.wantErr)
+				}
+				if article.Content != tt.content {
+					t.Errorf("content should be unchanged on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessContext() returned error: %v", err)
+			}
+
+			if got := article.Metadata[MetadataExtractionStrategy]; got != tt.wantStrategy {
+				t.Errorf("strategy = %v, want %q", got, tt.wantStrategy)
+			}
+			if article.URL != server.URL+tt.path {
+				t.Errorf("URL = %q, should be unchanged", article.URL)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(article.Content, want) {
+					t.Errorf("content should contain %q, got:\n%s", want, article.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestFallbackProcessor_Process(t *testing.T) {
+	article := &models.Article{
+		URL:     "https://example.com/story",
+		Content: "<p>" + strings.Repeat("Feed text. ", 200) + "</p>",
+	}
+	opts := DefaultOptions()
+	opts.MinContentLength = 0
+
+	if err := NewFallbackProcessor().Process(article, &opts); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	if got := article.Metadata[MetadataExtractionStrategy]; got != StrategyFeed {
+		t.Errorf("strategy = %v, want %q without a fetcher", got, StrategyFeed)
+	}
+}
+
+func fallbackPage(text string) string {
+	return fmt.Sprintf(`<html><head><title>Story</title></head><body>
+		<nav>Home</nav><article><h1>Story</h1><p>%s</p></article></body></html>`, text)
+}
+
+func TestSummaryContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		want    string
+	}{
+		{
+			name:    "text",
+			summary: "A summary & more",
+			want:    "<p>A summary &amp; more</p>\n",
+		},
+		{
+			name:    "HTML",
+			summary: `<p>A <b>bold</b> summary &amp; more</p><p><a href="/x">Continue</a></p>`,
+			want:    "<p>A bold summary &amp; more</p>\n<p>Continue</p>\n",
+		},
+		{
+			name:    "markup only",
+			summary: `<img src="/x.png">`,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summaryContent(&models.Article{Summary: tt.summary})
+			if got != tt.want {
+				t.Errorf("summaryContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package processor
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// defaultQRCodeSize is the default width and height of QR codes, in pixels.
+const defaultQRCodeSize = 128
+
+// FootnotesProcessor turns an article's external links into numbered
+// footnotes, listing their URLs at the end of the article, so they can be
+// followed from paper-like screens where tapping links is awkward.
+type FootnotesProcessor struct{}
+
+// NewFootnotesProcessor creates a new instance of FootnotesProcessor.
+func NewFootnotesProcessor() *FootnotesProcessor {
+	return &FootnotesProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *FootnotesProcessor) Name() string {
+	return "footnotes"
+}
+
+// footnotesOptions holds the additional options of the footnotes processor.
+type footnotesOptions struct {
+	QRCodes bool `option:"qr_codes"`
+	QRSize  int  `option:"qr_size"`
+}
+
+// check checks that the QR code size is positive.
+func (o *footnotesOptions) check() error {
+	if o.QRSize <= 0 {
+		return fmt.Errorf("qr_size must be positive, got %d", o.QRSize)
+	}
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *FootnotesProcessor) NewOptions() any {
+	return &footnotesOptions{QRSize: defaultQRCodeSize}
+}
+
+// Process replaces every external link in the article with its text followed
+// by a superscript footnote number, and appends a numbered list of the links'
+// URLs to the article. Links to the same URL share a number. Links within the
+// article, such as "#section" anchors, and links that aren't to web pages,
+// such as "mailto:" links, are kept as they are.
+//
+// With the "qr_codes" additional option, each URL is also shown as a QR code
+// of "qr_size" pixels (128 by default).
+func (p *FootnotesProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	if article.Content == "" {
+		return nil
+	}
+
+	options, err := processorOptions[footnotesOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	doc, err := html.Parse(strings.NewReader(article.Content))
+	if err != nil {
+		return err
+	}
+
+	base, err := url.Parse(article.URL)
+	if err != nil {
+		return err
+	}
+
+	var links []*html.Node
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			links = append(links, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	prefix := footnotePrefix(article)
+	var urls []string
+	numbers := make(map[string]int)
+	for _, link := range links {
+		target, ok := externalLink(base, attrValue(link, "href"))
+		if !ok || link.Parent == nil {
+			continue
+		}
+
+		number, ok := numbers[target]
+		if !ok {
+			urls = append(urls, target)
+			number = len(urls)
+			numbers[target] = number
+		}
+
+		// The first reference to a footnote gets an ID for the footnote to
+		// link back to.
+		var refID string
+		if !ok {
+			refID = fmt.Sprintf("%sref-%d", prefix, number)
+		}
+		ref := footnoteRef(prefix, number, refID)
+
+		for c := link.FirstChild; c != nil; c = link.FirstChild {
+			link.RemoveChild(c)
+			link.Parent.InsertBefore(c, link)
+		}
+		link.Parent.InsertBefore(ref, link)
+		link.Parent.RemoveChild(link)
+	}
+
+	if len(urls) == 0 {
+		return nil
+	}
+
+	notes, err := footnoteList(prefix, urls, options.QRCodes, options.QRSize)
+	if err != nil {
+		return err
+	}
+
+	body := findElement(doc, "body")
+	if body == nil {
+		return errors.New("no body in parsed content")
+	}
+	body.AppendChild(notes)
+
+	article.Content = renderContent(doc, article.Content)
+	return nil
+}
+
+// externalLink resolves a link's href against base and reports whether it
+// points to a web page outside of the article.
+func externalLink(base *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	target := base.ResolveReference(ref)
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", false
+	}
+
+	withoutFragment := *target
+	withoutFragment.Fragment = ""
+	if ref.Fragment != "" && withoutFragment.String() == base.String() {
+		return "", false
+	}
+
+	return target.String(), true
+}
+
+// footnotePrefix returns the prefix of the IDs of an article's footnotes,
+// which is unique to the article so that footnotes don't clash when articles
+// are rendered into the same document.
+func footnotePrefix(article *models.Article) string {
+	h := fnv.New32a()
+	h.Write([]byte(article.URL + "\x00" + article.Title))
+	return fmt.Sprintf("fn-%08x-", h.Sum32())
+}
+
+// footnoteRef returns a superscript linking to a footnote.
+func footnoteRef(prefix string, number int, id string) *html.Node {
+	link := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "a",
+		DataAtom: atom.A,
+		Attr:     []html.Attribute{{Key: "href", Val: "#" + prefix + strconv.Itoa(number)}},
+	}
+	if id != "" {
+		setAttr(link, "id", id)
+	}
+	link.AppendChild(&html.Node{Type: html.TextNode, Data: "[" + strconv.Itoa(number) + "]"})
+
+	sup := &html.Node{Type: html.ElementNode, Data: "sup", DataAtom: atom.Sup}
+	sup.AppendChild(link)
+	return sup
+}
+
+// footnoteList returns the section listing an article's footnotes.
+func footnoteList(prefix string, urls []string, qrCodes bool, qrSize int) (*html.Node, error) {
+	var buf strings.Builder
+	buf.WriteString(`<section class="footnotes"><hr/><h2>Links</h2><ol>`)
+	for i, target := range urls {
+		number := i + 1
+		escaped := html.EscapeString(target)
+		fmt.Fprintf(&buf, `<li id="%s%d"><a href="%s">%s</a> `, prefix, number, escaped, escaped)
+		fmt.Fprintf(&buf, `<a href="#%sref-%d">↩</a>`, prefix, number)
+
+		if qrCodes {
+			png, err := qrcode.Encode(target, qrcode.Medium, qrSize)
+			if err != nil {
+				return nil, fmt.Errorf("generating QR code for %s: %w", target, err)
+			}
+			fmt.Fprintf(
+				&buf,
+				`<br/><img src="data:image/png;base64,%s" width="%d" height="%d" alt="QR code"/>`,
+				base64.StdEncoding.EncodeToString(png),
+				qrSize,
+				qrSize,
+			)
+		}
+		buf.WriteString("</li>")
+	}
+	buf.WriteString("</ol></section>")
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(buf.String()), body)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// findElement returns the first element with the given tag name.
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestFootnotesProcessor_Process(t *testing.T) {
+	content := `<p>Read <a href="https://go.dev/doc">the docs</a> and ` +
+		`<a href="/archive" title="Archive">our <em>archive</em></a>.</p>` +
+		`<p>See <a href="#part-2">part 2</a>, <a href="mailto:me@example.com">email me</a> ` +
+		`or <a href="https://go.dev/doc">the docs again</a>.</p>` +
+		`<h2 id="part-2">Part 2</h2>`
+
+	article := &models.Article{
+		Title:   "Links",
+		URL:     "https://example.com/posts/links",
+		Content: content,
+	}
+	opts := DefaultOptions()
+
+	if err := NewFootnotesProcessor().Process(article, &opts); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	prefix := footnotePrefix(article)
+	want := []string{
+		`Read the docs<sup><a href="#` + prefix + `1" id="` + prefix + `ref-1">[1]</a></sup>`,
+		`our <em>archive</em><sup><a href="#` + prefix + `2" id="` + prefix +
+			`ref-2">[2]</a></sup>`,
+		`the docs again<sup><a href="#` + prefix + `1">[1]</a></sup>`,
+		`<a href="#part-2">part 2</a>`,
+		`<a href="mailto:me@example.com">email me</a>`,
+		`<li id="` + prefix + `1"><a href="https://go.dev/doc">https://go.dev/doc</a>`,
+		`<li id="` + prefix + `2"><a href="https://example.com/archive">`,
+		`<a href="#` + prefix + `ref-2">↩</a>`,
+	}
+	for _, w := range want {
+		if !strings.Contains(article.Content, w) {
+			t.Errorf("content should contain %q, got:\n%s", w, article.Content)
+		}
+	}
+	if strings.Count(article.Content, "<li") != 2 {
+		t.Errorf("expected 2 footnotes, got:\n%s", article.Content)
+	}
+	if strings.HasPrefix(article.Content, "<html>") {
+		t.Errorf("fragments should stay fragments, got:\n%s", article.Content)
+	}
+
+	if err := NewSanitizerProcessor().Process(article, &opts); err != nil {
+		t.Fatalf("sanitizing returned error: %v", err)
+	}
+	for _, w := range []string{`id="` + prefix + `1"`, `href="#` + prefix + `1"`} {
+		if !strings.Contains(article.Content, w) {
+			t.Errorf("sanitized content should contain %q, got:\n%s", w, article.Content)
+		}
+	}
+}
+
+func TestFootnotesProcessor_Process_QRCodes(t *testing.T) {
+	article := &models.Article{
+		URL:     "https://example.com/post",
+		Content: `<p><a href="https://go.dev">Go</a></p>`,
+	}
+	opts := DefaultOptions()
+	opts.AdditionalOptions = map[string]any{"qr_codes": true, "qr_size": int64(64)}
+
+	if err := NewFootnotesProcessor().Process(article, &opts); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if !strings.Contains(article.Content, `<img src="data:image/png;base64,`) ||
+		!strings.Contains(article.Content, `width="64" height="64"`) {
+		t.Errorf("expected a 64px QR code image, got:\n%s", article.Content)
+	}
+}
+
+func TestFootnotesProcessor_Process_NoLinks(t *testing.T) {
+	content := `<p>No <a href="#top">external</a> links.</p>`
+	article := &models.Article{URL: "https://example.com/post", Content: content}
+	opts := DefaultOptions()
+
+	if err := NewFootnotesProcessor().Process(article, &opts); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if article.Content != content {
+		t.Errorf("content without external links should be unchanged, got:\n%s", article.Content)
+	}
+}
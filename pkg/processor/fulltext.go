@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// defaultSnippetWords is the default number of words under which an article's
+// content is considered a snippet rather than its full text.
+const defaultSnippetWords = 150
+
+// FullTextProcessor fetches the full page of articles whose source only
+// provides a snippet, such as feeds that only include an excerpt.
+type FullTextProcessor struct{}
+
+// NewFullTextProcessor creates a new instance of FullTextProcessor.
+func NewFullTextProcessor() *FullTextProcessor {
+	return &FullTextProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *FullTextProcessor) Name() string {
+	return "fulltext"
+}
+
+// fullTextOptions holds the additional options of the fulltext processor.
+type fullTextOptions struct {
+	MinWords int  `option:"min_words"`
+	Always   bool `option:"always"`
+}
+
+// check checks that the word count is not negative.
+func (o *fullTextOptions) check() error {
+	if o.MinWords < 0 {
+		return fmt.Errorf("min_words must not be negative, got %d", o.MinWords)
+	}
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *FullTextProcessor) NewOptions() any {
+	return &fullTextOptions{MinWords: defaultSnippetWords}
+}
+
+// ProcessContext replaces the article's content with the page at its URL if
+// the content has fewer words than the "min_words" additional option (150 by
+// default), or always if the "always" additional option is set. The snippet
+// becomes the article's summary if it has none. If fetching fails, the
+// snippet is kept and the error returned.
+//
+// Run it before readability, which extracts the article from the page.
+func (p *FullTextProcessor) ProcessContext(
+	ctx context.Context,
+	article *models.Article,
+	fetcher fetcher.Fetcher,
+	opts *Options,
+) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	if fetcher == nil {
+		return errors.New("fulltext processor requires a fetcher")
+	}
+
+	if article.URL == "" {
+		return nil
+	}
+
+	options, err := processorOptions[fullTextOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	snippet := article.Content
+	if !options.Always && models.CountWords(models.PlainText(snippet)) >= options.MinWords {
+		return nil
+	}
+
+	content, err := fetcher.FetchURLAsString(ctx, article.URL)
+	if err != nil {
+		return fmt.Errorf("fetching full text of %s: %w", article.URL, err)
+	}
+
+	article.Content = content
+	if article.Summary == "" {
+		article.Summary = models.PlainText(snippet)
+	}
+
+	return nil
+}
+
+// Process can't fetch anything, as it has no fetcher, and always fails.
+func (p *FullTextProcessor) Process(article *models.Article, opts *Options) error {
+	return p.ProcessContext(context.Background(), article, nil, opts)
+}
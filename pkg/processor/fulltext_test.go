@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestFullTextProcessor_ProcessContext(t *testing.T) {
+	const page = "<html><body><article><p>The full text.</p></article></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	snippet := "<p>A short snippet…</p>"
+	long := "<p>" + strings.Repeat("word ", 200) + "</p>"
+
+	tests := []struct {
+		name        string
+		path        string
+		content     string
+		options     map[string]any
+		wantContent string
+		wantSummary string
+		wantErr     bool
+	}{
+		{
+			name:        "fetches snippets",
+			path:        "/story",
+			content:     snippet,
+			wantContent: page,
+			wantSummary: "A short snippet…",
+		},
+		{
+			name:        "keeps full content",
+			path:        "/story",
+			content:     long,
+			wantContent: long,
+		},
+		{
+			name:        "custom snippet length",
+			path:        "/story",
+			content:     snippet,
+			options:     map[string]any{"min_words": int64(2)},
+			wantContent: snippet,
+		},
+		{
+			name:        "always fetches",
+			path:        "/story",
+			content:     long,
+			options:     map[string]any{"always": true},
+			wantContent: page,
+			wantSummary: strings.TrimSpace(strings.Repeat("word ", 200)),
+		},
+		{
+			name:        "keeps snippet when fetching fails",
+			path:        "/missing",
+			content:     snippet,
+			wantContent: snippet,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{URL: server.URL + tt.path, Content: tt.content}
+			opts := DefaultOptions()
+			if tt.options != nil {
+				opts.AdditionalOptions = tt.options
+			}
+
+			err := NewFullTextProcessor().ProcessContext(
+				context.Background(),
+				article,
+				fetcher.NewHTTPFetcher(),
+				&opts,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if article.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", article.Content, tt.wantContent)
+			}
+			if strings.TrimSpace(article.Summary) != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", article.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestFullTextProcessor_Process_NoFetcher(t *testing.T) {
+	article := &models.Article{URL: "https://example.com/story", Content: "<p>Snippet</p>"}
+	if err := NewFullTextProcessor().Process(article, &Options{}); err == nil {
+		t.Error("Process() expected error without a fetcher, got nil")
+	}
+}
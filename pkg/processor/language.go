@@ -0,0 +1,358 @@
+package processor
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// LanguageProcessor detects the language of an article's text and records it
+// in the article's metadata under models.MetadataLanguage.
+type LanguageProcessor struct{}
+
+// NewLanguageProcessor creates a new instance of LanguageProcessor.
+func NewLanguageProcessor() *LanguageProcessor {
+	return &LanguageProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *LanguageProcessor) Name() string {
+	return "language"
+}
+
+// languageOptions holds the additional options of the language processor.
+type languageOptions struct {
+	Overwrite bool `option:"overwrite"`
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *LanguageProcessor) NewOptions() any {
+	return &languageOptions{Overwrite: true}
+}
+
+// Process detects the article's language from its title, summary and
+// content. If the language can't be detected, any language already recorded,
+// e.g. by the article's source, is kept. Set the "overwrite" additional
+// option to false to always keep an existing language.
+func (p *LanguageProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	options, err := processorOptions[languageOptions](p, opts)
+	if err != nil {
+		return err
+	}
+	existing, _ := article.Metadata[models.MetadataLanguage].(string)
+	if existing != "" && !options.Overwrite {
+		return nil
+	}
+
+	text := strings.Join([]string{
+		article.Title,
+		models.PlainText(article.Summary),
+		models.PlainText(article.Content),
+	}, "\n")
+
+	lang := detectLanguage(text)
+	if lang == "" {
+		return nil
+	}
+
+	if article.Metadata == nil {
+		article.Metadata = make(map[string]any)
+	}
+	article.Metadata[models.MetadataLanguage] = lang
+
+	return nil
+}
+
+const (
+	// ngramProfileSize is the number of most frequent n-grams kept in a
+	// language profile.
+	ngramProfileSize = 300
+
+	// maxNgramSize is the length of the longest n-grams in a profile.
+	maxNgramSize = 3
+
+	// minDetectionLetters is the number of letters a text needs for its
+	// language to be detected reliably.
+	minDetectionLetters = 20
+
+	// detectionSampleRunes is how much of a text is used for detection.
+	// Longer texts take longer without detecting any better.
+	detectionSampleRunes = 10000
+
+	// maxDetectionDistance is the furthest a text's profile can be from a
+	// language's, as a fraction of the largest possible distance, for the
+	// text to be in that language. Texts in languages without a profile,
+	// like Finnish or Polish, are further from every profile.
+	maxDetectionDistance = 0.59
+
+	// minDetectionMargin is how much closer, in the same units, a text must
+	// be to its language than to the next closest, so that texts between
+	// two languages aren't detected as either.
+	minDetectionMargin = 0.02
+)
+
+// languageProfiles are the n-gram profiles of the languages written in the
+// Latin script that can be detected, built from languageSamples.
+var languageProfiles = buildLanguageProfiles()
+
+func buildLanguageProfiles() map[string]map[string]int {
+	profiles := make(map[string]map[string]int, len(languageSamples))
+	for lang, sample := range languageSamples {
+		profiles[lang] = ngramProfile(sample)
+	}
+	return profiles
+}
+
+// detectLanguage returns the ISO 639-1 code of the language text is written
+// in, or an empty string if it can't tell.
+//
+// Texts mostly in a script used by few languages, such as Greek or Hangul,
+// are identified by their script. Texts in the Latin script are compared
+// against each language's profile of its most frequent character n-grams,
+// using the "out-of-place" distance of Cavnar and Trenkle's N-Gram-Based
+// Text Categorization. Texts that aren't close enough to any profile, or are
+// about as close to two, are in a language it can't tell.
+func detectLanguage(text string) string {
+	runes := []rune(text)
+	if len(runes) > detectionSampleRunes {
+		runes = runes[:detectionSampleRunes]
+	}
+	text = string(runes)
+
+	lang, letters := scriptLanguage(text)
+	if letters < minDetectionLetters || lang != "" {
+		return lang
+	}
+
+	profile := ngramProfile(text)
+	best, bestDistance, runnerUpDistance := "", -1, -1
+	for candidate, candidateProfile := range languageProfiles {
+		distance := profileDistance(profile, candidateProfile)
+		switch {
+		case bestDistance < 0 || distance < bestDistance ||
+			(distance == bestDistance && candidate < best):
+			runnerUpDistance = bestDistance
+			best, bestDistance = candidate, distance
+		case runnerUpDistance < 0 || distance < runnerUpDistance:
+			runnerUpDistance = distance
+		}
+	}
+
+	maxDistance := float64(len(profile) * ngramProfileSize)
+	if float64(bestDistance) > maxDetectionDistance*maxDistance ||
+		float64(runnerUpDistance-bestDistance) < minDetectionMargin*maxDistance {
+		return ""
+	}
+
+	return best
+}
+
+// scriptLanguage counts the letters in text and, if most of them are in a
+// script that identifies a language, returns that language.
+func scriptLanguage(text string) (string, int) {
+	counts := make(map[string]int)
+	letters := 0
+	ukrainian := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		}
+	}
+
+	script, most := "", 0
+	for candidate, count := range counts {
+		if count > most || (count == most && candidate < script) {
+			script, most = candidate, count
+		}
+	}
+
+	// Japanese mixes kana with Han characters, so any amount of kana marks
+	// text as Japanese rather than Chinese.
+	if script == "zh" && counts["ja"] > 0 {
+		script = "ja"
+	}
+	if script == "ru" && ukrainian {
+		script = "uk"
+	}
+	if script == "latin" || most*2 < letters {
+		script = ""
+	}
+
+	return script, letters
+}
+
+// ngramProfile returns the ranks of the most frequent 1 to maxNgramSize
+// character n-grams in text, with words padded by spaces so n-grams at the
+// start and end of words are distinct.
+func ngramProfile(text string) map[string]int {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		padded := []rune(" " + word + " ")
+		for n := 1; n <= maxNgramSize; n++ {
+			for i := 0; i+n <= len(padded); i++ {
+				ngram := string(padded[i : i+n])
+				if ngram != " " {
+					counts[ngram]++
+				}
+			}
+		}
+	}
+
+	ngrams := make([]string, 0, len(counts))
+	for ngram := range counts {
+		ngrams = append(ngrams, ngram)
+	}
+	slices.SortFunc(ngrams, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	profile := make(map[string]int, ngramProfileSize)
+	for rank, ngram := range ngrams[:min(ngramProfileSize, len(ngrams))] {
+		profile[ngram] = rank
+	}
+	return profile
+}
+
+// profileDistance sums how far each n-gram of a text's profile is from its
+// rank in a language's profile, with n-grams missing from the language
+// profile counting as the furthest possible.
+func profileDistance(text, language map[string]int) int {
+	distance := 0
+	for ngram, rank := range text {
+		languageRank, ok := language[ngram]
+		if !ok {
+			distance += ngramProfileSize
+			continue
+		}
+		distance += max(rank-languageRank, languageRank-rank)
+	}
+	return distance
+}
+
+// languageSamples are sample texts the n-gram profiles of each language are
+// built from.
+var languageSamples = map[string]string{
+	"en": `The city council met on Tuesday evening to discuss the new budget for
+		the coming year. Several members said that the plan would not provide
+		enough money for schools, while others argued that taxes were already
+		too high. After a long debate, they agreed to hold another meeting
+		next week. Scientists have found that the ocean is warming faster than
+		they expected, which could have serious effects on fish and the people
+		who depend on them. The report was written by researchers from many
+		countries and it shows that these changes are happening everywhere.
+		Our team spent the weekend working on the software release, and we
+		think this version is the best one yet. You can download it from the
+		website, where you will also find the documentation and a short
+		guide that explains how everything works together.`,
+	"de": `Der Stadtrat hat sich am Dienstagabend getroffen, um über den neuen
+		Haushalt für das kommende Jahr zu sprechen. Mehrere Mitglieder sagten,
+		dass der Plan nicht genug Geld für die Schulen vorsieht, während
+		andere meinten, dass die Steuern schon jetzt zu hoch seien. Nach einer
+		langen Debatte einigten sie sich darauf, in der nächsten Woche noch
+		einmal zusammenzukommen. Wissenschaftler haben herausgefunden, dass
+		sich das Meer schneller erwärmt als erwartet, was ernste Folgen für
+		die Fische und die Menschen haben könnte, die von ihnen abhängen. Der
+		Bericht wurde von Forschern aus vielen Ländern geschrieben und zeigt,
+		dass diese Veränderungen überall geschehen. Unser Team hat am
+		Wochenende an der neuen Version gearbeitet, und wir glauben, dass sie
+		die beste bisher ist. Sie können sie auf der Webseite herunterladen.`,
+	"fr": `Le conseil municipal s'est réuni mardi soir pour discuter du nouveau
+		budget de l'année prochaine. Plusieurs membres ont déclaré que le
+		projet ne prévoyait pas assez d'argent pour les écoles, tandis que
+		d'autres estimaient que les impôts étaient déjà trop élevés. Après un
+		long débat, ils ont accepté de se retrouver la semaine prochaine. Des
+		scientifiques ont découvert que l'océan se réchauffe plus vite que
+		prévu, ce qui pourrait avoir des conséquences graves pour les poissons
+		et pour les gens qui en dépendent. Le rapport a été écrit par des
+		chercheurs de nombreux pays et il montre que ces changements se
+		produisent partout. Notre équipe a travaillé tout le week-end sur la
+		nouvelle version du logiciel, et nous pensons que c'est la meilleure
+		jusqu'à présent. Vous pouvez la télécharger sur notre site.`,
+	"es": `El ayuntamiento se reunió el martes por la noche para hablar del
+		nuevo presupuesto para el próximo año. Varios miembros dijeron que el
+		plan no ofrecía suficiente dinero para las escuelas, mientras que
+		otros afirmaron que los impuestos ya eran demasiado altos. Después de
+		un largo debate, acordaron volver a reunirse la semana que viene. Los
+		científicos han descubierto que el océano se está calentando más
+		rápido de lo esperado, lo que podría tener efectos graves para los
+		peces y para las personas que dependen de ellos. El informe fue
+		escrito por investigadores de muchos países y muestra que estos
+		cambios están ocurriendo en todas partes. Nuestro equipo pasó el fin
+		de semana trabajando en la nueva versión del programa, y creemos que
+		es la mejor hasta ahora. Puede descargarla desde nuestra página.`,
+	"it": `Il consiglio comunale si è riunito martedì sera per discutere del
+		nuovo bilancio per il prossimo anno. Diversi membri hanno detto che il
+		piano non prevedeva abbastanza soldi per le scuole, mentre altri
+		sostenevano che le tasse fossero già troppo alte. Dopo un lungo
+		dibattito, hanno deciso di incontrarsi di nuovo la settimana
+		prossima. Gli scienziati hanno scoperto che l'oceano si sta
+		riscaldando più velocemente del previsto, il che potrebbe avere
+		effetti gravi per i pesci e per le persone che dipendono da loro. Il
+		rapporto è stato scritto da ricercatori di molti paesi e mostra che
+		questi cambiamenti stanno avvenendo ovunque. La nostra squadra ha
+		lavorato tutto il fine settimana alla nuova versione del programma, e
+		pensiamo che sia la migliore finora. Potete scaricarla dal sito.`,
+	"pt": `A câmara municipal reuniu-se na terça-feira à noite para discutir o
+		novo orçamento para o próximo ano. Vários membros disseram que o plano
+		não oferecia dinheiro suficiente para as escolas, enquanto outros
+		afirmaram que os impostos já eram demasiado altos. Depois de um longo
+		debate, concordaram em voltar a reunir-se na semana que vem. Os
+		cientistas descobriram que o oceano está a aquecer mais depressa do
+		que se esperava, o que pode ter efeitos graves para os peixes e para
+		as pessoas que dependem deles. O relatório foi escrito por
+		investigadores de muitos países e mostra que estas mudanças estão a
+		acontecer em todo o lado. A nossa equipa passou o fim de semana a
+		trabalhar na nova versão do programa, e achamos que é a melhor até
+		agora. Pode descarregá-la no nosso site, onde também encontra ajuda.`,
+	"nl": `De gemeenteraad kwam dinsdagavond bijeen om over de nieuwe begroting
+		voor het komende jaar te praten. Verschillende leden zeiden dat het
+		plan niet genoeg geld voor de scholen bevatte, terwijl anderen vonden
+		dat de belastingen nu al te hoog zijn. Na een lang debat spraken ze
+		af om volgende week opnieuw bij elkaar te komen. Wetenschappers hebben
+		ontdekt dat de oceaan sneller opwarmt dan verwacht, wat ernstige
+		gevolgen kan hebben voor de vissen en voor de mensen die van hen
+		afhankelijk zijn. Het rapport is geschreven door onderzoekers uit
+		veel landen en laat zien dat deze veranderingen overal plaatsvinden.
+		Ons team heeft het hele weekend aan de nieuwe versie van het
+		programma gewerkt, en we denken dat het de beste tot nu toe is. U kunt
+		hem downloaden van onze website, waar u ook de handleiding vindt.`,
+}
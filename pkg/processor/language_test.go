@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{
+			text: "The new phone has a bigger battery and a brighter screen than last year's model.",
+			want: "en",
+		},
+		{
+			text: "Die Bahn hat angekündigt, dass die Preise im nächsten Monat wieder steigen werden.",
+			want: "de",
+		},
+		{
+			text: "Le gouvernement a annoncé une réforme des retraites qui sera votée cet automne.",
+			want: "fr",
+		},
+		{
+			text: "El equipo ganó el partido gracias a un gol en los últimos minutos del juego.",
+			want: "es",
+		},
+		{
+			text: "La squadra ha vinto la partita grazie a un gol negli ultimi minuti di gioco.",
+			want: "it",
+		},
+		{
+			text: "O governo anunciou novas medidas para reduzir o preço da energia este inverno.",
+			want: "pt",
+		},
+		{
+			text: "De trein naar Amsterdam had vanochtend een uur vertraging door een storing.",
+			want: "nl",
+		},
+		{text: "Правительство объявило о новых мерах по снижению цен на энергию.", want: "ru"},
+		{text: "Уряд оголосив про нові заходи щодо зниження цін на енергію.", want: "uk"},
+		{
+			text: "Η κυβέρνηση ανακοίνωσε νέα μέτρα για τη μείωση των τιμών της ενέργειας.",
+			want: "el",
+		},
+		{text: "政府はエネルギー価格を引き下げるための新たな措置を発表した。", want: "ja"},
+		{text: "政府宣布了降低能源价格的新措施，并将于下个月开始实施。", want: "zh"},
+		{text: "정부는 에너지 가격을 낮추기 위한 새로운 조치를 발표했습니다.", want: "ko"},
+		{text: "Too short", want: ""},
+		{text: "1234 5678 !!!", want: ""},
+
+		// Latin-script languages without a profile
+		{
+			text: "Hallitus ilmoitti uusista toimista energian hinnan alentamiseksi tänä talvena.",
+			want: "",
+		},
+		{
+			text: "Regeringen meddelade nya åtgärder för att sänka energipriserna i vinter.",
+			want: "",
+		},
+		{text: "Vlada je najavila nove mjere za smanjenje cijena energije ove zime.", want: ""},
+		{
+			text: "Pemerintah mengumumkan langkah baru untuk menurunkan harga energi musim " +
+				"dingin ini.",
+			want: "",
+		},
+		{text: "Rząd ogłosił nowe środki mające na celu obniżenie cen energii tej zimy.", want: ""},
+		{
+			text: "Yliopiston tutkijat ovat kehittäneet uuden akun, joka voidaan ladata alle " +
+				"kymmenessä minuutissa. Ryhmän mukaan tekniikkaa voitaisiin käyttää " +
+				"sähköautoissa viiden vuoden kuluessa, vaikka useita ongelmia on vielä " +
+				"ratkaistava. Valmistajat ovat osoittaneet kiinnostusta, mutta materiaalien " +
+				"hinta on edelleen korkea.",
+			want: "",
+		},
+
+		// Short snippets
+		{text: "Neue Regeln für Mieter ab Januar", want: "de"},
+		{text: "Go 1.22 released with range over integers", want: "en"},
+		{text: "Hyvää huomenta kaikille lukijoille", want: ""},
+		{text: "Selamat pagi semua pembaca", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+": "+tt.text, func(t *testing.T) {
+			if got := detectLanguage(tt.text); got != tt.want {
+				t.Errorf("detectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageProcessor_Process(t *testing.T) {
+	german := "<p>Die Bahn hat angekündigt, dass die Preise im nächsten Monat steigen.</p>"
+
+	tests := []struct {
+		name     string
+		content  string
+		existing string
+		options  map[string]any
+		want     string
+	}{
+		{name: "detects language", content: german, want: "de"},
+		{name: "overwrites by default", content: german, existing: "en", want: "de"},
+		{
+			name:     "keeps existing language",
+			content:  german,
+			existing: "en",
+			options:  map[string]any{"overwrite": false},
+			want:     "en",
+		},
+		{
+			name:     "keeps language when undetectable",
+			content:  "<p>OK</p>",
+			existing: "fr",
+			want:     "fr",
+		},
+		{
+			name:     "keeps language when unsupported",
+			content:  "<p>Vlada je najavila nove mjere za smanjenje cijena energije ove zime.</p>",
+			existing: "hr",
+			want:     "hr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{Content: tt.content, Metadata: map[string]any{}}
+			if tt.existing != "" {
+				article.Metadata[models.MetadataLanguage] = tt.existing
+			}
+			opts := DefaultOptions()
+			if tt.options != nil {
+				opts.AdditionalOptions = tt.options
+			}
+
+			if err := NewLanguageProcessor().Process(article, &opts); err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			if got := article.Metadata[models.MetadataLanguage]; got != tt.want {
+				t.Errorf("language = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
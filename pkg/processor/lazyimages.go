@@ -0,0 +1,433 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// defaultLazyImageMaxWidth is the default width of the largest srcset
+// candidate that is promoted. Larger candidates are only picked if there's
+// nothing smaller.
+const defaultLazyImageMaxWidth = 1600
+
+// lazySrcAttrs are attributes lazy-loading scripts read an image's URL from,
+// in order of preference.
+var lazySrcAttrs = []string{
+	"data-src",
+	"data-lazy-src",
+	"data-original",
+	"data-lazy",
+	"data-url",
+	"data-hi-res-src",
+}
+
+// lazySrcsetAttrs are attributes lazy-loading scripts read an image's srcset
+// from, in order of preference.
+var lazySrcsetAttrs = []string{"data-srcset", "data-lazy-srcset", "srcset"}
+
+// placeholderName matches file names commonly used for placeholder images.
+var placeholderName = regexp.MustCompile(
+	`(?i)(^|[/_.-])(placeholder|blank|spacer|transparent|lazy|loading|1x1)` +
+		`([_.-][^/]*)?\.(gif|png|svg)$`,
+)
+
+// LazyImagesProcessor repairs images that are only loaded by scripts, which
+// would otherwise end up as placeholders once extracted or embedded.
+type LazyImagesProcessor struct{}
+
+// NewLazyImagesProcessor creates a new instance of LazyImagesProcessor.
+func NewLazyImagesProcessor() *LazyImagesProcessor {
+	return &LazyImagesProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *LazyImagesProcessor) Name() string {
+	return "lazyimages"
+}
+
+// lazyImagesOptions holds the additional options of the lazyimages
+// processor.
+type lazyImagesOptions struct {
+	MaxWidth int `option:"max_width"`
+}
+
+// check checks that the maximum width is not negative.
+func (o *lazyImagesOptions) check() error {
+	if o.MaxWidth < 0 {
+		return fmt.Errorf("max_width must not be negative, got %d", o.MaxWidth)
+	}
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *LazyImagesProcessor) NewOptions() any {
+	return &lazyImagesOptions{MaxWidth: defaultLazyImageMaxWidth}
+}
+
+// Process promotes the best image URL from an image's srcset, lazy-loading
+// data-* attributes, enclosing <picture> or <noscript> fallback into its src,
+// and resolves image URLs against the article's URL. Placeholder images with
+// nothing to promote are removed. srcset candidates wider than the
+// "max_width" additional option (1600 by default) are avoided.
+//
+// Run it before readability, which may otherwise drop images it thinks are
+// placeholders.
+func (p *LazyImagesProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	if article.Content == "" {
+		return nil
+	}
+
+	options, err := processorOptions[lazyImagesOptions](p, opts)
+	if err != nil {
+		return err
+	}
+	maxWidth := options.MaxWidth
+
+	// With scripting disabled, <noscript> content is parsed as markup rather
+	// than text, so its images can be found.
+	doc, err := html.ParseWithOptions(
+		strings.NewReader(article.Content),
+		html.ParseOptionEnableScripting(false),
+	)
+	if err != nil {
+		return err
+	}
+
+	base, err := url.Parse(article.URL)
+	if err != nil {
+		return err
+	}
+
+	var noscripts, imgs []*html.Node
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "noscript":
+				noscripts = append(noscripts, n)
+			case "img":
+				imgs = append(imgs, n)
+			case "source":
+				if srcset := attrValue(n, "data-srcset"); srcset != "" {
+					setAttr(n, "srcset", srcset)
+					removeAttr(n, "data-srcset")
+				}
+				if srcset := attrValue(n, "srcset"); srcset != "" {
+					setAttr(n, "srcset", resolveSrcset(base, srcset))
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	// Images inside replaced <noscript> elements are dropped from the tree,
+	// and aren't repaired.
+	replaced := make(map[*html.Node]bool)
+	for _, noscript := range noscripts {
+		if img := repairNoscript(noscript); img != nil {
+			replaced[img] = true
+		}
+	}
+
+	for _, img := range imgs {
+		if !replaced[img] && img.Parent != nil {
+			repairImage(img, base, maxWidth)
+		}
+	}
+
+	article.Content = renderContent(doc, article.Content)
+	return nil
+}
+
+// repairNoscript replaces a lazy-loaded image with the image in the
+// <noscript> element following it, or unwraps the <noscript> if it only holds
+// an image. It returns the lazy-loaded image if it was replaced.
+func repairNoscript(noscript *html.Node) *html.Node {
+	var fallback *html.Node
+	for c := noscript.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.ElementNode && c.Data == "img" && fallback == nil:
+			fallback = c
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) == "":
+		default:
+			return nil
+		}
+	}
+	if fallback == nil || noscript.Parent == nil {
+		return nil
+	}
+
+	prev := noscript.PrevSibling
+	for prev != nil && prev.Type == html.TextNode && strings.TrimSpace(prev.Data) == "" {
+		prev = prev.PrevSibling
+	}
+
+	noscript.RemoveChild(fallback)
+	if prev != nil && prev.Type == html.ElementNode && prev.Data == "img" {
+		if !isPlaceholder(attrValue(prev, "src")) || bestImageCandidate(prev, 0) != "" {
+			// The script-loaded image already has a usable URL, so the
+			// fallback is only a duplicate.
+			noscript.Parent.RemoveChild(noscript)
+			return nil
+		}
+
+		noscript.Parent.InsertBefore(fallback, prev)
+		noscript.Parent.RemoveChild(prev)
+		noscript.Parent.RemoveChild(noscript)
+		return prev
+	}
+
+	noscript.Parent.InsertBefore(fallback, noscript)
+	noscript.Parent.RemoveChild(noscript)
+	return nil
+}
+
+// repairImage points an image's src at the best URL it has, resolved against
+// base, and removes lazy-loading attributes. Placeholders without any other
+// URL are removed.
+func repairImage(img *html.Node, base *url.URL, maxWidth int) {
+	src := attrValue(img, "src")
+	if src == "" || isPlaceholder(src) {
+		src = bestImageCandidate(img, maxWidth)
+	}
+	if src == "" {
+		img.Parent.RemoveChild(img)
+		return
+	}
+
+	setAttr(img, "src", resolveImageURL(base, src))
+	if srcset := firstAttr(img, lazySrcsetAttrs); srcset != "" {
+		setAttr(img, "srcset", resolveSrcset(base, srcset))
+	}
+	for _, attr := range append(slices.Clone(lazySrcAttrs), "data-srcset", "data-lazy-srcset") {
+		removeAttr(img, attr)
+	}
+	if attrValue(img, "loading") == "lazy" {
+		removeAttr(img, "loading")
+	}
+}
+
+// bestImageCandidate returns the best URL for an image other than its src:
+// the largest srcset candidate of the image or its enclosing <picture> that is
+// at most maxWidth wide (or the smallest one otherwise), or else the first
+// lazy-loading data-* attribute. A maxWidth of 0 means no limit.
+func bestImageCandidate(img *html.Node, maxWidth int) string {
+	srcsets := make([]string, 0, 2)
+	if srcset := firstAttr(img, lazySrcsetAttrs); srcset != "" {
+		srcsets = append(srcsets, srcset)
+	}
+	if picture := img.Parent; picture != nil && picture.Data == "picture" {
+		for c := picture.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "source" {
+				continue
+			}
+			if srcset := firstAttr(c, lazySrcsetAttrs); srcset != "" {
+				srcsets = append(srcsets, srcset)
+			}
+		}
+	}
+
+	var candidates []srcsetCandidate
+	for _, srcset := range srcsets {
+		for _, candidate := range parseSrcset(srcset) {
+			if !isPlaceholder(candidate.url) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	if best := pickCandidate(candidates, maxWidth); best != "" {
+		return best
+	}
+
+	for _, attr := range lazySrcAttrs {
+		if src := attrValue(img, attr); src != "" && !isPlaceholder(src) {
+			return src
+		}
+	}
+
+	return ""
+}
+
+// srcsetCandidate is an image candidate from a srcset attribute. Its size is
+// its width descriptor, or its pixel density descriptor scaled by 1000 so
+// that densities and widths roughly compare.
+type srcsetCandidate struct {
+	url     string
+	size    float64
+	isWidth bool
+}
+
+// parseSrcset parses a srcset attribute into its candidates. Candidates
+// without a descriptor count as 1x.
+func parseSrcset(srcset string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	for part := range strings.SplitSeq(srcset, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		candidate := srcsetCandidate{url: fields[0], size: 1000}
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			value, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64)
+			switch {
+			case err != nil:
+			case strings.HasSuffix(descriptor, "w"):
+				candidate.size = value
+				candidate.isWidth = true
+			case strings.HasSuffix(descriptor, "x"):
+				candidate.size = value * 1000
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// pickCandidate returns the URL of the largest candidate, skipping those
+// whose width descriptor is over maxWidth unless all of them are, in which
+// case the smallest is picked.
+func pickCandidate(candidates []srcsetCandidate, maxWidth int) string {
+	var best, smallest *srcsetCandidate
+	for i := range candidates {
+		c := &candidates[i]
+		if smallest == nil || c.size < smallest.size {
+			smallest = c
+		}
+		if c.isWidth && maxWidth > 0 && c.size > float64(maxWidth) {
+			continue
+		}
+		if best == nil || c.size > best.size {
+			best = c
+		}
+	}
+
+	switch {
+	case best != nil:
+		return best.url
+	case smallest != nil:
+		return smallest.url
+	}
+	return ""
+}
+
+// resolveSrcset resolves every candidate URL in a srcset against base.
+func resolveSrcset(base *url.URL, srcset string) string {
+	parts := strings.Split(srcset, ",")
+	resolved := make([]string, 0, len(parts))
+	for _, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = resolveImageURL(base, fields[0])
+		resolved = append(resolved, strings.Join(fields, " "))
+	}
+	return strings.Join(resolved, ", ")
+}
+
+// resolveImageURL resolves an image URL against base. Data URIs and
+// unparseable URLs are returned unchanged.
+func resolveImageURL(base *url.URL, src string) string {
+	if strings.HasPrefix(src, "data:") {
+		return src
+	}
+	ref, err := url.Parse(strings.TrimSpace(src))
+	if err != nil {
+		return src
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// isPlaceholder reports whether an image URL is most likely a placeholder
+// shown until a script loads the real image: an empty URL, an inline GIF or
+// SVG, or a file named like a placeholder.
+func isPlaceholder(src string) bool {
+	src = strings.TrimSpace(src)
+	if src == "" || src == "#" || strings.HasPrefix(src, "about:") {
+		return true
+	}
+	if strings.HasPrefix(src, "data:image/gif") || strings.HasPrefix(src, "data:image/svg") {
+		return true
+	}
+	if u, err := url.Parse(src); err == nil {
+		return placeholderName.MatchString(u.Path)
+	}
+	return false
+}
+
+// renderContent renders a parsed document back to HTML. If the original
+// content was a fragment, only the body's content is rendered.
+func renderContent(doc *html.Node, original string) string {
+	var buf strings.Builder
+	if err := html.Render(&buf, doc); err != nil {
+		return original
+	}
+
+	content := buf.String()
+	if !isDocument(original) {
+		content = strings.TrimPrefix(content, "<html><head></head><body>")
+		content = strings.TrimSuffix(content, "</body></html>")
+	}
+	return content
+}
+
+// isDocument reports whether content is a whole HTML document, such as a
+// fetched page, rather than a fragment, such as a feed's content.
+func isDocument(content string) bool {
+	lower := strings.ToLower(content)
+	return strings.Contains(lower, "<html") || strings.Contains(lower, "<body")
+}
+
+func firstAttr(n *html.Node, keys []string) string {
+	for _, key := range keys {
+		if v := attrValue(n, key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, attr := range n.Attr {
+		if attr.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeAttr(n *html.Node, key string) {
+	n.Attr = slices.DeleteFunc(n.Attr, func(attr html.Attribute) bool {
+		return attr.Key == key
+	})
+}
@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestLazyImagesProcessor_Process(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		options map[string]any
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "data-src replaces placeholder",
+			content: `<p><img src="/img/placeholder.gif" data-src="photos/cat.jpg" alt="Cat"></p>`,
+			want:    []string{`src="https://example.com/posts/photos/cat.jpg"`, `alt="Cat"`},
+			notWant: []string{"placeholder.gif", "data-src"},
+		},
+		{
+			name: "inline placeholder with data-lazy-src",
+			content: `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" ` +
+				`data-lazy-src="/cat.jpg">`,
+			want:    []string{`src="https://example.com/cat.jpg"`},
+			notWant: []string{"data:image/gif"},
+		},
+		{
+			name: "largest srcset candidate within max width",
+			content: `<img data-srcset="/cat-400.jpg 400w, /cat-1200.jpg 1200w, ` +
+				`/cat-2400.jpg 2400w">`,
+			want: []string{
+				`src="https://example.com/cat-1200.jpg"`,
+				`srcset="https://example.com/cat-400.jpg 400w, ` +
+					`https://example.com/cat-1200.jpg 1200w, ` +
+					`https://example.com/cat-2400.jpg 2400w"`,
+			},
+			notWant: []string{"data-srcset"},
+		},
+		{
+			name:    "max width option",
+			content: `<img srcset="/cat-400.jpg 400w, /cat-1200.jpg 1200w">`,
+			options: map[string]any{"max_width": int64(800)},
+			want:    []string{`src="https://example.com/cat-400.jpg"`},
+		},
+		{
+			name:    "density descriptors",
+			content: `<img srcset="/cat.jpg, /cat@2x.jpg 2x">`,
+			want:    []string{`src="https://example.com/cat@2x.jpg"`},
+		},
+		{
+			name: "picture sources",
+			content: `<picture><source data-srcset="/cat.webp 800w" type="image/webp">` +
+				`<img src="/lazy-placeholder.png"></picture>`,
+			want: []string{
+				`src="https://example.com/cat.webp"`,
+				`srcset="https://example.com/cat.webp 800w"`,
+			},
+			notWant: []string{"lazy-placeholder.png"},
+		},
+		{
+			name: "noscript fallback replaces placeholder",
+			content: `<p><img class="lazyload" src="/blank.gif">` +
+				`<noscript><img src="/cat.jpg" alt="Cat"></noscript></p>`,
+			want:    []string{`<p><img src="https://example.com/cat.jpg" alt="Cat"/></p>`},
+			notWant: []string{"noscript", "blank.gif"},
+		},
+		{
+			name: "noscript duplicate of working image",
+			content: `<p><img data-src="/cat.jpg"><noscript><img src="/cat-small.jpg">` +
+				`</noscript></p>`,
+			want:    []string{`<p><img src="https://example.com/cat.jpg"/></p>`},
+			notWant: []string{"cat-small.jpg"},
+		},
+		{
+			name:    "lone noscript image is unwrapped",
+			content: `<figure><noscript><img src="/cat.jpg"></noscript></figure>`,
+			want:    []string{`<figure><img src="https://example.com/cat.jpg"/></figure>`},
+		},
+		{
+			name:    "placeholder without candidates is removed",
+			content: `<p>Text<img src="/spacer.gif"></p>`,
+			want:    []string{"<p>Text</p>"},
+		},
+		{
+			name:    "regular images are resolved and kept",
+			content: `<img src="../pixel-art.png" loading="lazy">`,
+			want:    []string{`<img src="https://example.com/pixel-art.png"/>`},
+		},
+		{
+			name: "full documents stay full documents",
+			content: `<html><head><title>T</title></head>` +
+				`<body><img data-src="/a.jpg"></body></html>`,
+			want: []string{"<title>T</title>", `<body><img src="https://example.com/a.jpg"/>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{
+				URL:     "https://example.com/posts/lazy",
+				Content: tt.content,
+			}
+			opts := DefaultOptions()
+			if tt.options != nil {
+				opts.AdditionalOptions = tt.options
+			}
+
+			if err := NewLazyImagesProcessor().Process(article, &opts); err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(article.Content, want) {
+					t.Errorf("content should contain %q, got:\n%s", want, article.Content)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(article.Content, notWant) {
+					t.Errorf("content should not contain %q, got:\n%s", notWant, article.Content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// defaultMaxPages is the default maximum number of pages stitched together.
+const defaultMaxPages = 5
+
+// MetadataPageCount is the metadata key holding the number of pages an
+// article was stitched together from.
+const MetadataPageCount = "page_count"
+
+// nextPageText matches the text of links to an article's next page.
+var nextPageText = regexp.MustCompile(
+	`(?i)^(next( page)?|continue|weiter|suivant|siguiente)?\s*(»|›|→|>|>>)?$`,
+)
+
+// pageNumberQuery are query parameters holding page numbers.
+var pageNumberQuery = []string{"page", "p", "pg", "paged", "pagenum", "pagina"}
+
+// pageNumberPath matches a trailing page number in a URL path, e.g. "/2" or
+// "/page/2". Longer numbers are more likely to be IDs or years.
+var pageNumberPath = regexp.MustCompile(`(/page)?/\d{1,3}/?$`)
+
+// MultiPageProcessor extracts articles that are split across several pages,
+// following their "next page" links and stitching the pages together.
+type MultiPageProcessor struct {
+	readability *ReadabilityProcessor
+	siteConfig  *SiteConfigProcessor
+}
+
+// NewMultiPageProcessor creates a new instance of MultiPageProcessor.
+func NewMultiPageProcessor() *MultiPageProcessor {
+	return &MultiPageProcessor{
+		readability: NewReadabilityProcessor(),
+		siteConfig:  NewSiteConfigProcessor(),
+	}
+}
+
+// Name returns the name of this processor.
+func (p *MultiPageProcessor) Name() string {
+	return "multipage"
+}
+
+// multiPageOptions holds the additional options of the multipage processor,
+// including those of the siteconfig and readability processors it extracts
+// pages with.
+type multiPageOptions struct {
+	siteConfigOptions
+
+	MaxPages int `option:"max_pages"`
+}
+
+// check checks the page limit and the options passed on.
+func (o *multiPageOptions) check() error {
+	if o.MaxPages <= 0 {
+		return fmt.Errorf("max_pages must be positive, got %d", o.MaxPages)
+	}
+	return o.siteConfigOptions.check()
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *MultiPageProcessor) NewOptions() any {
+	return &multiPageOptions{
+		siteConfigOptions: newSiteConfigOptions(),
+		MaxPages:          defaultMaxPages,
+	}
+}
+
+// Process extracts the article's content, then follows its next page links,
+// extracting each page and appending it to the content, up to the
+// "max_pages" additional option (5 by default). Pages are fetched with the
+// source's fetcher, so its rate limit applies. Without a fetcher, only the
+// first page is extracted.
+//
+// Pages are extracted with readability, or with site configs if the
+// "directory" additional option is set, in which case a config's
+// single_page_link is preferred over following next_page_link. Without site
+// configs, next pages are found from rel="next" links and links reading
+// "Next", as long as they lead to another page of the same article.
+//
+// Run it instead of readability, as it needs the full HTML of each page.
+func (p *MultiPageProcessor) ProcessContext(
+	ctx context.Context,
+	article *models.Article,
+	fetcher fetcher.Fetcher,
+	opts *Options,
+) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	if article.Content == "" {
+		return nil
+	}
+
+	options, err := processorOptions[multiPageOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	useSiteConfigs := options.Directory != ""
+	siteConfigOpts := withOptions(opts, &options.siteConfigOptions)
+	readabilityOpts := withOptions(opts, &options.readabilityOptions)
+	extract := func(page *models.Article) error {
+		if useSiteConfigs {
+			return p.siteConfig.Process(page, siteConfigOpts)
+		}
+		return p.readability.Process(page, readabilityOpts)
+	}
+
+	nextURL := findNextPage(article.Content, article.URL)
+	if err := extract(article); err != nil {
+		return err
+	}
+
+	if fetcher == nil {
+		return nil
+	}
+
+	if singlePageURL, ok := article.Metadata[MetadataSinglePageURL].(string); ok {
+		page, err := fetchPage(ctx, fetcher, singlePageURL)
+		if err == nil {
+			err = extract(page)
+		}
+		if err != nil {
+			log.Printf("Error fetching single page version of %s: %v", article.URL, err)
+		} else {
+			article.Content = page.Content
+			return nil
+		}
+	}
+
+	if next, ok := article.Metadata[MetadataNextPageURL].(string); ok && useSiteConfigs {
+		nextURL = next
+	}
+
+	seen := map[string]bool{models.CanonicalURL(article.URL): true}
+	contents := []string{article.Content}
+	for nextURL != "" && len(contents) < options.MaxPages {
+		if seen[models.CanonicalURL(nextURL)] {
+			break
+		}
+		seen[models.CanonicalURL(nextURL)] = true
+
+		page, err := fetchPage(ctx, fetcher, nextURL)
+		if err != nil {
+			log.Printf("Error fetching page %d of %s: %v", len(contents)+1, article.URL, err)
+			break
+		}
+
+		nextURL = findNextPage(page.Content, page.URL)
+		if err := extract(page); err != nil {
+			log.Printf("Error extracting page %d of %s: %v", len(contents)+1, article.URL, err)
+			break
+		}
+		if next, ok := page.Metadata[MetadataNextPageURL].(string); ok && useSiteConfigs {
+			nextURL = next
+		}
+		contents = append(contents, page.Content)
+	}
+
+	if len(contents) > 1 {
+		article.Content = strings.Join(contents, "\n")
+		if article.Metadata == nil {
+			article.Metadata = make(map[string]any)
+		}
+		article.Metadata[MetadataPageCount] = len(contents)
+		delete(article.Metadata, MetadataNextPageURL)
+	}
+
+	return nil
+}
+
+// Process extracts the article's first page only, as it has no fetcher.
+func (p *MultiPageProcessor) Process(article *models.Article, opts *Options) error {
+	return p.ProcessContext(context.Background(), article, nil, opts)
+}
+
+// fetchPage fetches another page of an article.
+func fetchPage(
+	ctx context.Context,
+	fetcher fetcher.Fetcher,
+	pageURL string,
+) (*models.Article, error) {
+	content, err := fetcher.FetchURLAsString(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Article{URL: pageURL, Content: content}, nil
+}
+
+// findNextPage returns the URL of the next page of an article from its HTML,
+// or "" if there is none. Candidates are rel="next" links and links reading
+// "Next" or similar, and must lead to another page of the same article.
+func findNextPage(content, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+
+	var byRel, byText string
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if byRel != "" {
+			return
+		}
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "link") {
+			href := attrValue(n, "href")
+			ref, err := url.Parse(href)
+			if href != "" && err == nil {
+				target := base.ResolveReference(ref)
+				if isNextPageOf(target, base) {
+					rels := strings.Fields(strings.ToLower(attrValue(n, "rel")))
+					text := strings.Join(strings.Fields(nodeText(n)), " ")
+					if slices.Contains(rels, "next") {
+						byRel = target.String()
+						return
+					}
+					if byText == "" && n.Data == "a" && text != "" &&
+						nextPageText.MatchString(text) {
+						byText = target.String()
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	if byRel != "" {
+		return byRel
+	}
+	return byText
+}
+
+// isNextPageOf reports whether next is another page of the same article as
+// current: a different URL on the same host that only differs by a page
+// number in its query or at the end of its path.
+func isNextPageOf(next, current *url.URL) bool {
+	if next.Scheme != "http" && next.Scheme != "https" {
+		return false
+	}
+	if !strings.EqualFold(next.Hostname(), current.Hostname()) {
+		return false
+	}
+	if models.CanonicalURL(next.String()) == models.CanonicalURL(current.String()) {
+		return false
+	}
+
+	nextKey, paged := pageKey(next, true)
+	if !paged {
+		return false
+	}
+
+	// The current page is either the first page, whose path has no page
+	// number, or a later page.
+	firstKey, _ := pageKey(current, false)
+	laterKey, _ := pageKey(current, true)
+	return nextKey == firstKey || nextKey == laterKey
+}
+
+// pageKey returns a URL's path and query without page number query
+// parameters, and without a trailing page number in its path if stripPath is
+// set. It also reports whether any page number was removed.
+func pageKey(u *url.URL, stripPath bool) (string, bool) {
+	paged := false
+
+	path := u.Path
+	if loc := pageNumberPath.FindStringIndex(path); stripPath && loc != nil {
+		path = path[:loc[0]]
+		paged = true
+	}
+	path = strings.TrimSuffix(path, "/")
+
+	query := u.Query()
+	for _, key := range pageNumberQuery {
+		if query.Has(key) {
+			query.Del(key)
+			paged = true
+		}
+	}
+
+	return path + "?" + query.Encode(), paged
+}
+
+// nodeText returns the text of a node, including the alt text of images.
+func nodeText(n *html.Node) string {
+	switch {
+	case n.Type == html.TextNode:
+		return n.Data
+	case n.Type == html.ElementNode && n.Data == "img":
+		return attrValue(n, "alt")
+	}
+
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(nodeText(c))
+		b.WriteString(" ")
+	}
+	return b.String()
+}
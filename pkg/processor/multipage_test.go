@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestFindNextPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		pageURL string
+		content string
+		want    string
+	}{
+		{
+			name:    "rel next link",
+			pageURL: "https://example.com/story",
+			content: `<head><link rel="next" href="/story?page=2"></head>`,
+			want:    "https://example.com/story?page=2",
+		},
+		{
+			name:    "next page text with path number",
+			pageURL: "https://example.com/posts/12345",
+			content: `<a href="/posts/12345/2">Next page »</a>`,
+			want:    "https://example.com/posts/12345/2",
+		},
+		{
+			name:    "from a later page",
+			pageURL: "https://example.com/story/page/2",
+			content: `<a href="/story/page/1">Previous</a><a href="/story/page/3">›</a>`,
+			want:    "https://example.com/story/page/3",
+		},
+		{
+			name:    "next article is not a next page",
+			pageURL: "https://example.com/posts/12345",
+			content: `<a rel="next" href="/posts/12346">Next</a>`,
+		},
+		{
+			name:    "other host",
+			pageURL: "https://example.com/story",
+			content: `<a rel="next" href="https://other.example.com/story?page=2">Next</a>`,
+		},
+		{
+			name:    "unrelated link text",
+			pageURL: "https://example.com/story",
+			content: `<a href="/story?page=2">Comments</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findNextPage(tt.content, tt.pageURL); got != tt.want {
+				t.Errorf("findNextPage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPageProcessor_Process(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := r.URL.Query().Get("page")
+		fmt.Fprint(w, multiPageHTML(page, page != "4"))
+	}))
+	defer server.Close()
+
+	newArticle := func() *models.Article {
+		return &models.Article{URL: server.URL + "/story", Content: multiPageHTML("1", true)}
+	}
+
+	t.Run("stitches pages up to the cap", func(t *testing.T) {
+		requests = 0
+		article := newArticle()
+		opts := DefaultOptions()
+		opts.MinContentLength = 0
+		opts.AdditionalOptions = map[string]any{"max_pages": int64(3)}
+		err := NewMultiPageProcessor().ProcessContext(
+			context.Background(),
+			article,
+			fetcher.NewHTTPFetcher(),
+			&opts,
+		)
+		if err != nil {
+			t.Fatalf("ProcessContext() returned error: %v", err)
+		}
+
+		for page := 1; page <= 3; page++ {
+			if !strings.Contains(article.Content, pageText(fmt.Sprint(page))) {
+				t.Errorf("content should contain page %d, got:\n%s", page, article.Content)
+			}
+		}
+		if strings.Contains(article.Content, pageText("4")) {
+			t.Errorf("content should stop at the page cap, got:\n%s", article.Content)
+		}
+		if requests != 2 {
+			t.Errorf("expected 2 requests, got %d", requests)
+		}
+		if article.Metadata[MetadataPageCount] != 3 {
+			t.Errorf("page_count = %v, want 3", article.Metadata[MetadataPageCount])
+		}
+	})
+
+	t.Run("stops at the last page", func(t *testing.T) {
+		article := newArticle()
+		opts := DefaultOptions()
+		opts.MinContentLength = 0
+		err := NewMultiPageProcessor().ProcessContext(
+			context.Background(),
+			article,
+			fetcher.NewHTTPFetcher(),
+			&opts,
+		)
+		if err != nil {
+			t.Fatalf("ProcessContext() returned error: %v", err)
+		}
+		if article.Metadata[MetadataPageCount] != 4 {
+			t.Errorf("page_count = %v, want 4", article.Metadata[MetadataPageCount])
+		}
+	})
+
+	t.Run("without a fetcher", func(t *testing.T) {
+		article := newArticle()
+		opts := DefaultOptions()
+		opts.MinContentLength = 0
+
+		if err := NewMultiPageProcessor().Process(article, &opts); err != nil {
+			t.Fatalf("Process() returned error: %v", err)
+		}
+		if !strings.Contains(article.Content, pageText("1")) ||
+			strings.Contains(article.Content, pageText("2")) {
+			t.Errorf("only the first page should be extracted, got:\n%s", article.Content)
+		}
+	})
+}
+
+func TestIsNextPageOf(t *testing.T) {
+	current, _ := url.Parse("https://example.com/story?id=7")
+	next, _ := url.Parse("https://example.com/story?id=7&page=2")
+	other, _ := url.Parse("https://example.com/story?id=8&page=2")
+
+	if !isNextPageOf(next, current) {
+		t.Error("page 2 of the same story should be a next page")
+	}
+	if isNextPageOf(other, current) {
+		t.Error("page 2 of another story should not be a next page")
+	}
+}
+
+func pageText(page string) string {
+	return fmt.Sprintf("This is the text of page %s of the story.", page)
+}
+
+func multiPageHTML(page string, hasNext bool) string {
+	next := ""
+	if hasNext {
+		var n int
+		fmt.Sscan(page, &n)
+		next = fmt.Sprintf(`<a href="/story?page=%d">Next</a>`, n+1)
+	}
+
+	return fmt.Sprintf(`<html><head><title>Story</title></head><body>
+		<article><h1>Story</h1><p>%s %s</p></article>%s</body></html>`,
+		pageText(page),
+		strings.Repeat("It goes on at some length to look like an article. ", 5),
+		next,
+	)
+}
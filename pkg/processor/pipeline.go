@@ -0,0 +1,139 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// Policies for handling a processor's errors.
+const (
+	// OnErrorKeep logs the error and continues with the next processor
+	OnErrorKeep = "keep"
+
+	// OnErrorDrop drops the article from the digest
+	OnErrorDrop = "drop"
+
+	// OnErrorSkipRest keeps the article as it is, without running the
+	// remaining processors
+	OnErrorSkipRest = "skip_rest"
+
+	// OnErrorFallbackSummary replaces the article's content with its summary
+	// and a link to read it online and continues, or drops the article if it
+	// has no summary
+	OnErrorFallbackSummary = "fallback_summary"
+)
+
+// ValidateOnError checks that policy is a valid on_error policy. An empty
+// policy means OnErrorKeep.
+func ValidateOnError(policy string) error {
+	switch policy {
+	case "", OnErrorKeep, OnErrorDrop, OnErrorSkipRest, OnErrorFallbackSummary:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"invalid on_error %q: must be one of %q, %q, %q or %q",
+		policy,
+		OnErrorKeep,
+		OnErrorDrop,
+		OnErrorSkipRest,
+		OnErrorFallbackSummary,
+	)
+}
+
+// DropError is returned by Run when a processor's failure drops an article.
+// Processors can also return it to drop an article whatever their OnError
+// policy.
+type DropError struct {
+	// Processor is the name of the processor that failed
+	Processor string
+
+	// Err is the processor's error
+	Err error
+}
+
+func (e *DropError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Processor, e.Err)
+}
+
+func (e *DropError) Unwrap() error {
+	return e.Err
+}
+
+// Run processes an article with each processor in turn, with the options at
+// the same index, handling errors according to each processor's OnError
+// policy. It returns a *DropError if the article should be dropped from the
+// digest, ctx's error if it is cancelled, and nil otherwise; other errors are
+// logged.
+func Run(
+	ctx context.Context,
+	article *models.Article,
+	procs []Processor,
+	opts []Options,
+	fetcher fetcher.Fetcher,
+) error {
+	for i, proc := range procs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := Adapt(proc).ProcessContext(ctx, article, fetcher, &opts[i])
+		if err == nil {
+			continue
+		}
+
+		// Cancellation stops processing whatever the policy, as every
+		// remaining processor would fail too. Timeouts of single requests
+		// aren't cancellation, even though their errors match
+		// context.DeadlineExceeded, and are handled by the policy.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			log.Printf("Stopped processing %q: %v", article.Title, err)
+			return ctxErr
+		}
+
+		var dropErr *DropError
+		if errors.As(err, &dropErr) {
+			return dropErr
+		}
+
+		switch opts[i].OnError {
+		case OnErrorDrop:
+			return &DropError{Processor: proc.Name(), Err: err}
+
+		case OnErrorSkipRest:
+			log.Printf(
+				"Error processing %q with %s: %v; skipping remaining processors",
+				article.Title,
+				proc.Name(),
+				err,
+			)
+			return nil
+
+		case OnErrorFallbackSummary:
+			if len(models.Paragraphs(article.Summary)) == 0 {
+				return &DropError{
+					Processor: proc.Name(),
+					Err:       fmt.Errorf("%w, and there is no summary to fall back to", err),
+				}
+			}
+			log.Printf(
+				"Error processing %q with %s: %v; using its summary as content",
+				article.Title,
+				proc.Name(),
+				err,
+			)
+			article.Content = summaryContent(article)
+
+		default:
+			log.Printf("Error processing %q with %s: %v", article.Title, proc.Name(), err)
+		}
+
+	}
+
+	return nil
+}
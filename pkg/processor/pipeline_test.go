@@ -0,0 +1,185 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// fakeProcessor appends its name to the article's content, or fails.
+type fakeProcessor struct {
+	name string
+	err  error
+}
+
+func (p *fakeProcessor) Name() string { return p.name }
+
+func (p *fakeProcessor) Process(article *models.Article, _ *Options) error {
+	if p.err != nil {
+		return p.err
+	}
+	article.Content += p.name
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	procs := []Processor{
+		&fakeProcessor{name: "a"},
+		&fakeProcessor{name: "failing", err: ErrContentProcessingFailed},
+		&fakeProcessor{name: "b"},
+	}
+
+	tests := []struct {
+		name        string
+		onError     string
+		summary     string
+		wantContent string
+		wantDrop    bool
+	}{
+		{name: "keep by default", wantContent: "<raw>ab"},
+		{name: "keep", onError: OnErrorKeep, wantContent: "<raw>ab"},
+		{name: "drop", onError: OnErrorDrop, wantDrop: true},
+		{name: "skip rest", onError: OnErrorSkipRest, wantContent: "<raw>a"},
+		{
+			name:        "fallback summary",
+			onError:     OnErrorFallbackSummary,
+			summary:     "<p>A <b>short</b> summary &amp; more</p>",
+			wantContent: "<p>A short summary &amp; more</p>\nb",
+		},
+		{name: "fallback without summary", onError: OnErrorFallbackSummary, wantDrop: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{Title: "T", Content: "<raw>", Summary: tt.summary}
+			opts := []Options{{}, {OnError: tt.onError}, {}}
+
+			err := Run(context.Background(), article, procs, opts, nil)
+
+			var dropErr *DropError
+			if tt.wantDrop {
+				if !errors.As(err, &dropErr) {
+					t.Fatalf("Run() error = %v, want a DropError", err)
+				}
+				if dropErr.Processor != "failing" ||
+					!errors.Is(err, ErrContentProcessingFailed) {
+					t.Errorf("DropError = %v, want the failing processor's error", dropErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() returned error: %v", err)
+			}
+			if article.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", article.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestRun_DropErrorFromProcessor(t *testing.T) {
+	dropping := &fakeProcessor{
+		name: "dropping",
+		err:  &DropError{Processor: "dropping", Err: ErrContentProcessingFailed},
+	}
+	article := &models.Article{Title: "T", Content: "<raw>"}
+	opts := []Options{{OnError: OnErrorKeep}, {}}
+
+	err := Run(context.Background(), article, []Processor{dropping, &fakeProcessor{name: "b"}},
+		opts, nil)
+
+	var dropErr *DropError
+	if !errors.As(err, &dropErr) || dropErr.Processor != "dropping" {
+		t.Fatalf("Run() error = %v, want the processor's DropError", err)
+	}
+	if article.Content != "<raw>" {
+		t.Errorf("remaining processors should not run, got content %q", article.Content)
+	}
+}
+
+func TestRun_RequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	slowFetcher := fetcher.NewHTTPFetcher(fetcher.WithTimeout(50 * time.Millisecond))
+	procs := []Processor{NewFullTextProcessor(), &fakeProcessor{name: "b"}}
+
+	tests := []struct {
+		name        string
+		onError     string
+		wantContent string
+		wantDrop    bool
+	}{
+		{name: "keep", onError: OnErrorKeep, wantContent: "<p>Snippet</p>b"},
+		{name: "drop", onError: OnErrorDrop, wantDrop: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{Title: "T", URL: server.URL, Content: "<p>Snippet</p>"}
+			opts := []Options{DefaultOptions(), {}}
+			opts[0].OnError = tt.onError
+
+			err := Run(context.Background(), article, procs, opts, slowFetcher)
+
+			var dropErr *DropError
+			if tt.wantDrop {
+				if !errors.As(err, &dropErr) || dropErr.Processor != "fulltext" {
+					t.Fatalf("Run() error = %v, want a DropError from fulltext", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() returned error: %v", err)
+			}
+			if article.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", article.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	article := &models.Article{Title: "T", Content: "<raw>"}
+	procs := []Processor{&fakeProcessor{name: "a"}}
+
+	err := Run(ctx, article, procs, []Options{{OnError: OnErrorKeep}}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if article.Content != "<raw>" {
+		t.Errorf("processors should not run once cancelled, got content %q", article.Content)
+	}
+}
+
+func TestOptionsFromConfig_OnError(t *testing.T) {
+	opts, err := OptionsFromConfig(map[string]any{"on_error": "drop"})
+	if err != nil {
+		t.Fatalf("OptionsFromConfig() returned error: %v", err)
+	}
+	if opts.OnError != OnErrorDrop {
+		t.Errorf("OnError = %q, want %q", opts.OnError, OnErrorDrop)
+	}
+
+	for _, invalid := range []any{"ignore", 1} {
+		if _, err := OptionsFromConfig(map[string]any{"on_error": invalid}); err == nil {
+			t.Errorf("OptionsFromConfig(on_error = %v) expected error, got nil", invalid)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
+	"github.com/shrik450/dijester/pkg/fetcher"
 	"github.com/shrik450/dijester/pkg/models"
 )
 
@@ -17,6 +21,43 @@ type ProcessorConfig struct {
 	// ProcessorConfigs contains optional configuration for each processor. If
 	// a processor is not configured, it will use its default settings.
 	ProcessorConfigs map[string]any `toml:"processor_configs"`
+
+	// SanitizerPolicies are custom policies the sanitizer can use by name
+	SanitizerPolicies map[string]SanitizerPolicy `toml:"sanitizer_policies"`
+}
+
+// Inherit returns the config with the sanitizer policies of parent that it
+// doesn't define itself, so sources can use global custom policies.
+func (c ProcessorConfig) Inherit(parent ProcessorConfig) ProcessorConfig {
+	policies := make(map[string]SanitizerPolicy, len(parent.SanitizerPolicies))
+	maps.Copy(policies, parent.SanitizerPolicies)
+	maps.Copy(policies, c.SanitizerPolicies)
+	c.SanitizerPolicies = policies
+	return c
+}
+
+// Validate checks the config's sanitizer policies, and that its processors
+// exist and are configured with valid options, including processors that
+// are configured but not used.
+func (c ProcessorConfig) Validate() error {
+	for name, policy := range c.SanitizerPolicies {
+		switch name {
+		case PolicyStrict, PolicyUGC, PolicyEPUBSafe:
+			return fmt.Errorf("sanitizer_policies: %q is a built-in policy", name)
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("sanitizer_policies.%s: %w", name, err)
+		}
+	}
+
+	names := slices.Concat(c.Processors, slices.Sorted(maps.Keys(c.ProcessorConfigs)))
+	for _, name := range names {
+		if _, _, err := c.initialize(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Options contains configuration for content processors.
@@ -38,6 +79,14 @@ type Options struct {
 
 	// Additional processor-specific options
 	AdditionalOptions map[string]any
+
+	// OnError is the policy for handling the processor's errors, one of the
+	// OnError* constants. Empty means OnErrorKeep.
+	OnError string
+
+	// decoded holds the additional options decoded into the processor's
+	// options struct by DecodeOptions
+	decoded any
 }
 
 var defaultOptions = DefaultOptions()
@@ -63,9 +112,75 @@ type Processor interface {
 	Name() string
 }
 
+// ContextProcessor is the interface of processors that make requests, e.g. to
+// fetch an article's full text, and so need a context to be cancelled with and
+// the fetcher of the article's source. Processors that don't implement it can
+// be used as one with Adapt.
+type ContextProcessor interface {
+	// ProcessContext processes the article like Process, making any requests
+	// with fetcher
+	ProcessContext(
+		ctx context.Context,
+		article *models.Article,
+		fetcher fetcher.Fetcher,
+		opts *Options,
+	) error
+
+	// Name returns the name of this processor
+	Name() string
+}
+
+// Adapt returns p as a ContextProcessor. Processors that only implement
+// Process ignore the fetcher, and aren't run once ctx is done.
+func Adapt(p Processor) ContextProcessor {
+	if cp, ok := p.(ContextProcessor); ok {
+		return cp
+	}
+	return processorAdapter{p}
+}
+
+type processorAdapter struct {
+	Processor
+}
+
+func (a processorAdapter) ProcessContext(
+	ctx context.Context,
+	article *models.Article,
+	_ fetcher.Fetcher,
+	opts *Options,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Process(article, opts)
+}
+
+// DigestProcessor is implemented by processors that also need to see every
+// article in the digest at once, after all sources have been fetched and
+// processed, e.g. to compare articles with each other.
+type DigestProcessor interface {
+	Processor
+
+	// ProcessDigest processes all articles in the digest
+	ProcessDigest(articles []*models.Article, opts *Options) error
+}
+
 var availableProcessors = []string{
+	"cleanup",
+	"exec",
+	"fallback",
+	"footnotes",
+	"fulltext",
+	"language",
+	"lazyimages",
+	"multipage",
+	"quality",
 	"readability",
 	"sanitizer",
+	"siteconfig",
+	"stats",
+	"summarize",
+	"tagger",
 }
 
 // List returns a list of available processor names.
@@ -78,42 +193,128 @@ func List() []string {
 // New returns a new instance of the specified processor.
 func New(name string) (Processor, error) {
 	switch name {
+	case "cleanup":
+		return NewCleanupProcessor(), nil
+	case "exec":
+		return NewExecProcessor(), nil
+	case "fallback":
+		return NewFallbackProcessor(), nil
+	case "footnotes":
+		return NewFootnotesProcessor(), nil
+	case "fulltext":
+		return NewFullTextProcessor(), nil
+	case "language":
+		return NewLanguageProcessor(), nil
+	case "lazyimages":
+		return NewLazyImagesProcessor(), nil
+	case "multipage":
+		return NewMultiPageProcessor(), nil
+	case "quality":
+		return NewQualityProcessor(), nil
 	case "readability":
 		return NewReadabilityProcessor(), nil
 	case "sanitizer":
 		return NewSanitizerProcessor(), nil
+	case "siteconfig":
+		return NewSiteConfigProcessor(), nil
+	case "stats":
+		return NewStatsProcessor(), nil
+	case "summarize":
+		return NewSummarizeProcessor(), nil
+	case "tagger":
+		return NewTaggerProcessor(), nil
 	}
 
 	return nil, fmt.Errorf("processor not found: %s", name)
 }
 
+// commonSchema declares the settings every processor accepts besides its
+// additional options.
+var commonSchema = Schema{
+	"min_content_length": OptionInt,
+	"max_content_length": OptionInt,
+	"include_images":     OptionBool,
+	"include_tables":     OptionBool,
+	"include_videos":     OptionBool,
+	"on_error":           OptionString,
+}
+
+// OptionsFromConfig decodes a processor's settings from its configuration.
+// Unknown settings and values of the wrong type are errors. Additional
+// options are returned as they are; see DecodeOptions.
 func OptionsFromConfig(config map[string]any) (Options, error) {
 	opts := DefaultOptions()
 
-	if minLength, ok := config["min_content_length"].(int); ok {
-		opts.MinContentLength = minLength
+	common := maps.Clone(config)
+	delete(common, "additional_options")
+	decoded, err := commonSchema.Decode(common)
+	if err != nil {
+		return opts, err
 	}
 
-	if maxLength, ok := config["max_content_length"].(int); ok {
-		opts.MaxContentLength = maxLength
+	if v, ok := decoded["min_content_length"]; ok {
+		opts.MinContentLength = v.(int)
 	}
 
-	if includeImages, ok := config["include_images"].(bool); ok {
-		opts.IncludeImages = includeImages
+	if v, ok := decoded["max_content_length"]; ok {
+		opts.MaxContentLength = v.(int)
 	}
 
-	if includeTables, ok := config["include_tables"].(bool); ok {
-		opts.IncludeTables = includeTables
+	if v, ok := decoded["include_images"]; ok {
+		opts.IncludeImages = v.(bool)
 	}
 
-	if includeVideos, ok := config["include_videos"].(bool); ok {
-		opts.IncludeVideos = includeVideos
+	if v, ok := decoded["include_tables"]; ok {
+		opts.IncludeTables = v.(bool)
 	}
 
-	if additionalOptions, ok := config["additional_options"].(map[string]any); ok {
+	if v, ok := decoded["include_videos"]; ok {
+		opts.IncludeVideos = v.(bool)
+	}
+
+	if v, ok := config["additional_options"]; ok {
+		additionalOptions, ok := v.(map[string]any)
+		if !ok {
+			return opts, fmt.Errorf("additional_options must be a table, got %s", describeValue(v))
+		}
 		opts.AdditionalOptions = additionalOptions
 	}
 
+	if v, ok := decoded["on_error"]; ok {
+		onError := v.(string)
+		if err := ValidateOnError(onError); err != nil {
+			return opts, err
+		}
+		opts.OnError = onError
+	}
+
+	return opts, nil
+}
+
+// DecodeOptions decodes a processor's settings from its configuration like
+// OptionsFromConfig, and its additional options into the processor's options
+// struct, checking their values, so the processor doesn't decode them again
+// for every article.
+func DecodeOptions(p Processor, config map[string]any) (Options, error) {
+	opts, err := OptionsFromConfig(config)
+	if err != nil {
+		return opts, err
+	}
+
+	configurable, ok := p.(Configurable)
+	if !ok {
+		if _, err := (Schema{}).Decode(opts.AdditionalOptions); err != nil {
+			return opts, fmt.Errorf("additional_options: %w", err)
+		}
+		return opts, nil
+	}
+
+	decoded := configurable.NewOptions()
+	if err := decodeOptions(opts.AdditionalOptions, decoded); err != nil {
+		return opts, fmt.Errorf("additional_options: %w", err)
+	}
+	opts.decoded = decoded
+
 	return opts, nil
 }
 
@@ -126,25 +327,43 @@ func InitializeProcessors(cfg ProcessorConfig) ([]Processor, []Options, error) {
 	options := make([]Options, len(cfg.Processors))
 
 	for i, name := range cfg.Processors {
-		processor, err := New(name)
+		processor, opts, err := cfg.initialize(name)
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating processor %s: %w", name, err)
+			return nil, nil, err
 		}
+
 		processors[i] = processor
+		options[i] = opts
+	}
 
-		config, ok := cfg.ProcessorConfigs[name].(map[string]any)
-		if !ok {
-			config = make(map[string]any)
-		}
+	return processors, options, nil
+}
 
-		opts, err := OptionsFromConfig(config)
-		if err != nil {
-			return nil, nil, fmt.Errorf("processing options for %s: %w", name, err)
+// initialize creates the named processor and decodes its options.
+func (c ProcessorConfig) initialize(name string) (Processor, Options, error) {
+	processor, err := New(name)
+	if err != nil {
+		return nil, Options{}, fmt.Errorf("creating processor %s: %w", name, err)
+	}
+	if _, ok := processor.(*SanitizerProcessor); ok {
+		processor = NewSanitizerProcessorWithPolicies(c.SanitizerPolicies)
+	}
+
+	config := make(map[string]any)
+	if v, ok := c.ProcessorConfigs[name]; ok {
+		if config, ok = v.(map[string]any); !ok {
+			return nil, Options{}, fmt.Errorf(
+				"processing options for %s: processor_configs.%s must be a table",
+				name,
+				name,
+			)
 		}
+	}
 
-		processors[i] = processor
-		options[i] = opts
+	opts, err := DecodeOptions(processor, config)
+	if err != nil {
+		return nil, Options{}, fmt.Errorf("processing options for %s: %w", name, err)
 	}
 
-	return processors, options, nil
+	return processor, opts, nil
 }
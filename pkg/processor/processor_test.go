@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestAdapt(t *testing.T) {
+	fulltext := NewFullTextProcessor()
+	if Adapt(fulltext) != ContextProcessor(fulltext) {
+		t.Error("Adapt() should return context processors as they are")
+	}
+
+	adapted := Adapt(NewStatsProcessor())
+	if adapted.Name() != "stats" {
+		t.Errorf("Name() = %q, want %q", adapted.Name(), "stats")
+	}
+
+	article := &models.Article{Content: "<p>Some words here</p>"}
+	if err := adapted.ProcessContext(context.Background(), article, nil, nil); err != nil {
+		t.Fatalf("ProcessContext() returned error: %v", err)
+	}
+	if article.Metadata[models.MetadataWordCount] != 3 {
+		t.Errorf("word_count = %v, want 3", article.Metadata[models.MetadataWordCount])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	article = &models.Article{Content: "<p>Some words here</p>"}
+	err := adapted.ProcessContext(ctx, article, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ProcessContext() error = %v, want context.Canceled", err)
+	}
+	if article.Metadata != nil {
+		t.Error("a cancelled processor should not process the article")
+	}
+}
@@ -0,0 +1,345 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-shiori/go-readability"
+	"golang.org/x/net/html"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// MetadataQualityIssues is the metadata key listing the issues the quality
+// processor found with an article, as a []string of the Quality* constants.
+const MetadataQualityIssues = "quality_issues"
+
+// Issues the quality processor can find with an article.
+const (
+	// QualityPaywall is a paywall or subscription stub
+	QualityPaywall = "paywall"
+
+	// QualityCookieWall is a cookie or consent wall
+	QualityCookieWall = "cookie_wall"
+
+	// QualityJavaScript is a page asking to enable JavaScript
+	QualityJavaScript = "javascript"
+
+	// QualityErrorPage is an error, access denied or bot check page
+	QualityErrorPage = "error_page"
+
+	// QualityShort is content with fewer words than min_words
+	QualityShort = "short"
+
+	// QualityLinkHeavy is content that is mostly links
+	QualityLinkHeavy = "link_heavy"
+
+	// QualityUnreadable is a page readability wouldn't extract an article from
+	QualityUnreadable = "unreadable"
+)
+
+// Actions the quality processor can take on articles with issues.
+const (
+	// QualityActionFlag only records the issues in the article's metadata
+	QualityActionFlag = "flag"
+
+	// QualityActionDrop drops the article from the digest
+	QualityActionDrop = "drop"
+
+	// QualityActionSummary replaces the article's content with its summary
+	// and a link to read it online
+	QualityActionSummary = "summary"
+)
+
+const (
+	// defaultQualityMinWords is the default number of words below which
+	// content is too short.
+	defaultQualityMinWords = 50
+
+	// defaultQualityStubWords is the default number of words below which
+	// known phrases mark content as a stub. Longer articles often mention
+	// subscribing or cookies in passing.
+	defaultQualityStubWords = 300
+
+	// defaultMaxLinkDensity is the default share of text in links above
+	// which content is mostly links.
+	defaultMaxLinkDensity = 0.5
+)
+
+// qualityPhrases are phrases, in lowercase, that give away stub pages.
+var qualityPhrases = map[string][]string{
+	QualityPaywall: {
+		"subscribe to continue reading",
+		"subscribe to read",
+		"subscribe to unlock",
+		"subscribers only",
+		"for subscribers",
+		"already a subscriber",
+		"already have an account? sign in",
+		"to continue reading, please",
+		"this content is only available",
+		"become a member to read",
+		"you have reached your limit of free articles",
+		"you've reached your free article limit",
+		"create a free account to continue",
+	},
+	QualityCookieWall: {
+		"we use cookies",
+		"accept all cookies",
+		"accept cookies to continue",
+		"manage cookie preferences",
+		"cookie settings",
+		"before you continue to",
+		"we value your privacy",
+	},
+	QualityJavaScript: {
+		"enable javascript",
+		"javascript is disabled",
+		"javascript is required",
+		"requires javascript",
+		"turn on javascript",
+		"please enable js",
+	},
+	QualityErrorPage: {
+		"page not found",
+		"404 not found",
+		"403 forbidden",
+		"access denied",
+		"something went wrong",
+		"this page isn't available",
+		"this page could not be found",
+		"are you a robot",
+		"verify you are human",
+		"checking your browser",
+		"too many requests",
+		"service unavailable",
+	},
+}
+
+// QualityProcessor flags articles that look like paywall stubs, cookie walls,
+// "enable JavaScript" pages or error pages rather than real articles, so they
+// don't end up as junk chapters in the digest.
+type QualityProcessor struct{}
+
+// NewQualityProcessor creates a new instance of QualityProcessor.
+func NewQualityProcessor() *QualityProcessor {
+	return &QualityProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *QualityProcessor) Name() string {
+	return "quality"
+}
+
+// qualityOptions holds the additional options of the quality processor.
+type qualityOptions struct {
+	Action         string              `option:"action"`
+	MinWords       int                 `option:"min_words"`
+	StubWords      int                 `option:"stub_words"`
+	MaxLinkDensity float64             `option:"max_link_density"`
+	Ignore         []string            `option:"ignore"`
+	Phrases        map[string][]string `option:"phrases"`
+
+	// phrases are the known phrases for each issue, with Phrases added
+	phrases map[string][]string
+}
+
+// check checks the action, limits and phrases, and adds the phrases to the
+// known ones.
+func (o *qualityOptions) check() error {
+	switch o.Action {
+	case QualityActionFlag, QualityActionDrop, QualityActionSummary:
+	default:
+		return fmt.Errorf(
+			"invalid action %q: must be one of %q, %q or %q",
+			o.Action,
+			QualityActionFlag,
+			QualityActionDrop,
+			QualityActionSummary,
+		)
+	}
+
+	if o.MinWords < 0 {
+		return fmt.Errorf("min_words must not be negative, got %d", o.MinWords)
+	}
+	if o.StubWords < 0 {
+		return fmt.Errorf("stub_words must not be negative, got %d", o.StubWords)
+	}
+	if o.MaxLinkDensity <= 0 {
+		return fmt.Errorf("max_link_density must be positive, got %g", o.MaxLinkDensity)
+	}
+
+	o.phrases = make(map[string][]string, len(qualityPhrases))
+	for issue, list := range qualityPhrases {
+		o.phrases[issue] = slices.Clone(list)
+	}
+	for issue, list := range o.Phrases {
+		if _, ok := qualityPhrases[issue]; !ok {
+			return fmt.Errorf("phrases: unknown issue %q", issue)
+		}
+		for _, phrase := range list {
+			o.phrases[issue] = append(o.phrases[issue], strings.ToLower(phrase))
+		}
+	}
+
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *QualityProcessor) NewOptions() any {
+	return &qualityOptions{
+		Action:         QualityActionFlag,
+		MinWords:       defaultQualityMinWords,
+		StubWords:      defaultQualityStubWords,
+		MaxLinkDensity: defaultMaxLinkDensity,
+	}
+}
+
+// Process checks the article's content for issues and lists them in its
+// metadata. The "action" additional option decides what happens to articles
+// with issues: "flag" (the default) only records them, "drop" drops the
+// article, and "summary" replaces its content with its summary and a link to
+// read it online.
+//
+// Content is short with fewer than "min_words" words (50 by default), and
+// link heavy when more than "max_link_density" (0.5 by default) of its text
+// is in links. Known phrases only count in content with fewer than
+// "stub_words" words (300 by default); the "phrases" additional option adds
+// phrases for each issue. Issues in the "ignore" additional option aren't
+// checked.
+func (p *QualityProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	options, err := processorOptions[qualityOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	issues := checkQuality(article.Content, options)
+	issues = slices.DeleteFunc(issues, func(issue string) bool {
+		return slices.Contains(options.Ignore, issue)
+	})
+	if len(issues) == 0 {
+		return nil
+	}
+
+	if article.Metadata == nil {
+		article.Metadata = make(map[string]any)
+	}
+	article.Metadata[MetadataQualityIssues] = issues
+
+	switch options.Action {
+	case QualityActionDrop:
+		return &DropError{
+			Processor: p.Name(),
+			Err:       fmt.Errorf("low quality content: %s", strings.Join(issues, ", ")),
+		}
+	case QualityActionSummary:
+		content := summaryContent(article)
+		if content == "" {
+			return &DropError{
+				Processor: p.Name(),
+				Err: fmt.Errorf(
+					"low quality content: %s, and there is no summary to fall back to",
+					strings.Join(issues, ", "),
+				),
+			}
+		}
+		article.Content = content
+	}
+
+	return nil
+}
+
+// checkQuality returns the issues found with content, in a stable order.
+func checkQuality(content string, options *qualityOptions) []string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+
+	text, linkText, noscriptText := qualityText(doc)
+	words := models.CountWords(text)
+
+	var issues []string
+	if words < options.StubWords {
+		lower := strings.ToLower(strings.Join(strings.Fields(text+" "+noscriptText), " "))
+		for _, issue := range []string{
+			QualityPaywall,
+			QualityCookieWall,
+			QualityJavaScript,
+			QualityErrorPage,
+		} {
+			if slices.ContainsFunc(options.phrases[issue], func(phrase string) bool {
+				return strings.Contains(lower, phrase)
+			}) {
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	if words < options.MinWords {
+		issues = append(issues, QualityShort)
+	}
+
+	textLen := len(strings.Join(strings.Fields(text), ""))
+	linkLen := len(strings.Join(strings.Fields(linkText), ""))
+	if textLen > 0 && float64(linkLen)/float64(textLen) > options.MaxLinkDensity {
+		issues = append(issues, QualityLinkHeavy)
+	}
+
+	// Readability's check is meant for whole pages, and would flag short
+	// but complete extracted articles.
+	if isDocument(content) && !readability.CheckDocument(doc) {
+		issues = append(issues, QualityUnreadable)
+	}
+
+	return issues
+}
+
+// qualityText returns the visible text of a document, the part of it that is
+// in links, and the text of its <noscript> elements. Pages that need
+// JavaScript usually only say so in a <noscript> element.
+func qualityText(doc *html.Node) (string, string, string) {
+	var text, linkText, noscriptText strings.Builder
+	var traverse func(n *html.Node, inLink bool)
+	traverse = func(n *html.Node, inLink bool) {
+		switch n.Type {
+		case html.TextNode:
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+			if inLink {
+				linkText.WriteString(n.Data)
+				linkText.WriteString(" ")
+			}
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "noscript":
+				// The parser keeps a <noscript> element's content as raw
+				// markup, as if scripts were enabled.
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if c.Type == html.TextNode {
+						noscriptText.WriteString(models.PlainText(c.Data))
+						noscriptText.WriteString(" ")
+					}
+				}
+				return
+			case "script", "style", "template", "head":
+				return
+			case "a":
+				inLink = true
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, inLink)
+		}
+	}
+	traverse(doc, false)
+
+	return text.String(), linkText.String(), noscriptText.String()
+}
@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestQualityProcessor_Process(t *testing.T) {
+	article := "<p>" + strings.Repeat("A real article has plenty to say about things. ", 20) +
+		`<a href="/more">Read more</a></p>`
+
+	tests := []struct {
+		name        string
+		content     string
+		summary     string
+		options     map[string]any
+		wantIssues  []string
+		wantContent string
+		wantDrop    bool
+	}{
+		{
+			name:    "real article",
+			content: article,
+		},
+		{
+			name:       "paywall stub",
+			content:    "<p>The first paragraph.</p><p>Subscribe to continue reading.</p>",
+			wantIssues: []string{QualityPaywall, QualityShort},
+		},
+		{
+			name: "cookie wall page",
+			content: `<html><body><div>We use cookies to improve your experience.
+				<button>Accept all cookies</button></div></body></html>`,
+			wantIssues: []string{QualityCookieWall, QualityShort, QualityUnreadable},
+		},
+		{
+			name:       "javascript page",
+			content:    "<p>You need to enable JavaScript to run this app.</p>",
+			wantIssues: []string{QualityJavaScript, QualityShort},
+		},
+		{
+			name: "javascript app shell",
+			content: `<!DOCTYPE html><html><head><title>News App</title>
+				<noscript><link rel="stylesheet" href="/noscript.css"></noscript></head>
+				<body><noscript><div class="warning"><p>You need to enable JavaScript to
+				run this app.</p></div></noscript><div id="root"></div>
+				<script src="/static/js/main.3f2a.js"></script></body></html>`,
+			wantIssues: []string{QualityJavaScript, QualityShort, QualityUnreadable},
+		},
+		{
+			name:       "phrases in a long article",
+			content:    article + "<p>We use cookies. Subscribe to read more like this.</p>",
+			options:    map[string]any{"stub_words": int64(100)},
+			wantIssues: nil,
+		},
+		{
+			name: "link heavy",
+			content: "<ul>" +
+				strings.Repeat(`<li><a href="/x">Another related story</a></li>`, 20) +
+				"</ul><p>Text.</p>",
+			wantIssues: []string{QualityLinkHeavy},
+		},
+		{
+			name:    "extra phrases",
+			content: "<p>Dieser Artikel ist nur für Abonnenten.</p>",
+			options: map[string]any{
+				"phrases": map[string]any{"paywall": []any{"Nur für Abonnenten"}},
+			},
+			wantIssues: []string{QualityPaywall, QualityShort},
+		},
+		{
+			name:       "ignored issues",
+			content:    "<p>Subscribe to read.</p>",
+			options:    map[string]any{"ignore": []any{"short"}},
+			wantIssues: []string{QualityPaywall},
+		},
+		{
+			name:       "summary action",
+			content:    "<p>Subscribe to read.</p>",
+			summary:    "What it's about",
+			options:    map[string]any{"action": "summary"},
+			wantIssues: []string{QualityPaywall, QualityShort},
+			wantContent: "<p>What it&#39;s about</p>\n" +
+				`<p><a href="https://example.com/post">Read online</a></p>`,
+		},
+		{
+			name:       "summary action with an HTML summary",
+			content:    "<p>Subscribe to read.</p>",
+			summary:    `<p>What it's <a href="/about">about</a></p><p>And more</p>`,
+			options:    map[string]any{"action": "summary"},
+			wantIssues: []string{QualityPaywall, QualityShort},
+			wantContent: "<p>What it&#39;s about</p>\n<p>And more</p>\n" +
+				`<p><a href="https://example.com/post">Read online</a></p>`,
+		},
+		{
+			name:       "drop action",
+			content:    "<p>Access denied.</p>",
+			options:    map[string]any{"action": "drop"},
+			wantIssues: []string{QualityErrorPage, QualityShort},
+			wantDrop:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &models.Article{
+				URL:     "https://example.com/post",
+				Content: tt.content,
+				Summary: tt.summary,
+			}
+			opts := DefaultOptions()
+			opts.AdditionalOptions = tt.options
+
+			err := NewQualityProcessor().Process(a, &opts)
+			var dropErr *DropError
+			switch {
+			case tt.wantDrop && !errors.As(err, &dropErr):
+				t.Fatalf("Process() error = %v, want a DropError", err)
+			case !tt.wantDrop && err != nil:
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			issues, _ := a.Metadata[MetadataQualityIssues].([]string)
+			if !slices.Equal(issues, tt.wantIssues) {
+				t.Errorf("issues = %v, want %v", issues, tt.wantIssues)
+			}
+
+			wantContent := tt.wantContent
+			if wantContent == "" {
+				wantContent = tt.content
+			}
+			if a.Content != wantContent {
+				t.Errorf("Content = %q, want %q", a.Content, wantContent)
+			}
+		})
+	}
+}
+
+func TestQualityProcessor_InvalidOptions(t *testing.T) {
+	for _, options := range []map[string]any{
+		{"action": "delete"},
+		{"ignore": "short"},
+		{"phrases": map[string]any{"spam": []any{"buy now"}}},
+	} {
+		opts := DefaultOptions()
+		opts.AdditionalOptions = options
+		article := &models.Article{Content: "<p>Text</p>"}
+		if err := NewQualityProcessor().Process(article, &opts); err == nil {
+			t.Errorf("Process() with %v expected error, got nil", options)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package processor
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"log"
 	"net/url"
 	"strings"
@@ -13,6 +14,18 @@ import (
 	"github.com/shrik450/dijester/pkg/models"
 )
 
+// Metadata keys holding page details found by readability.
+const (
+	// MetadataSiteName is the name of the site the article was published on
+	MetadataSiteName = "site_name"
+
+	// MetadataImage is the URL of the article's lead image
+	MetadataImage = "image"
+
+	// MetadataFavicon is the URL of the site's icon
+	MetadataFavicon = "favicon"
+)
+
 // ReadabilityProcessor uses go-readability to extract the main content from HTML.
 type ReadabilityProcessor struct{}
 
@@ -26,7 +39,72 @@ func (p *ReadabilityProcessor) Name() string {
 	return "readability"
 }
 
-// Process extracts the main content from an article's HTML content.
+// readabilityOptions holds the additional options of the readability
+// processor, which default to go-readability's defaults.
+type readabilityOptions struct {
+	MaxElemsToParse   int      `option:"max_elems_to_parse"`
+	NTopCandidates    int      `option:"n_top_candidates"`
+	CharThreshold     int      `option:"char_threshold"`
+	KeepClasses       bool     `option:"keep_classes"`
+	ClassesToPreserve []string `option:"classes_to_preserve"`
+	DisableJSONLD     bool     `option:"disable_jsonld"`
+
+	// LegacyClassesToPreserve is classes_to_preserve's original name, and
+	// replaces it if set.
+	LegacyClassesToPreserve []string `option:"classesToPreserve"`
+}
+
+func newReadabilityOptions() readabilityOptions {
+	parser := readability.NewParser()
+	return readabilityOptions{
+		MaxElemsToParse:   parser.MaxElemsToParse,
+		NTopCandidates:    parser.NTopCandidates,
+		CharThreshold:     parser.CharThresholds,
+		KeepClasses:       parser.KeepClasses,
+		ClassesToPreserve: parser.ClassesToPreserve,
+		DisableJSONLD:     parser.DisableJSONLD,
+	}
+}
+
+// check checks the number of top candidates, and applies the legacy name of
+// classes_to_preserve.
+func (o *readabilityOptions) check() error {
+	if o.NTopCandidates <= 0 {
+		return fmt.Errorf("n_top_candidates must be positive, got %d", o.NTopCandidates)
+	}
+	if o.LegacyClassesToPreserve != nil {
+		o.ClassesToPreserve = o.LegacyClassesToPreserve
+	}
+	return nil
+}
+
+// parser returns a parser configured by the options.
+func (o *readabilityOptions) parser() readability.Parser {
+	parser := readability.NewParser()
+	parser.MaxElemsToParse = o.MaxElemsToParse
+	parser.NTopCandidates = o.NTopCandidates
+	parser.CharThresholds = o.CharThreshold
+	parser.KeepClasses = o.KeepClasses
+	parser.ClassesToPreserve = o.ClassesToPreserve
+	parser.DisableJSONLD = o.DisableJSONLD
+	return parser
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *ReadabilityProcessor) NewOptions() any {
+	options := newReadabilityOptions()
+	return &options
+}
+
+// Process extracts the main content from an article's HTML content. The
+// parser is configured with the "max_elems_to_parse", "n_top_candidates",
+// "char_threshold", "keep_classes", "classes_to_preserve" and
+// "disable_jsonld" additional options, which default to go-readability's
+// defaults.
+//
+// Details readability finds in the page fill in the article's title, author,
+// summary, publication date and language if they are missing, and its site
+// name, lead image and icon are recorded in its metadata.
 func (p *ReadabilityProcessor) Process(article *models.Article, opts *Options) error {
 	if article == nil {
 		return errors.New("article cannot be nil")
@@ -36,15 +114,11 @@ func (p *ReadabilityProcessor) Process(article *models.Article, opts *Options) e
 		return nil
 	}
 
-	parser := readability.NewParser()
-
-	if opts != nil {
-		if v, ok := opts.AdditionalOptions["classesToPreserve"]; ok {
-			if classes, ok := v.([]string); ok {
-				parser.ClassesToPreserve = classes
-			}
-		}
+	options, err := processorOptions[readabilityOptions](p, opts)
+	if err != nil {
+		return err
 	}
+	parser := options.parser()
 
 	articleURL, err := url.Parse(article.URL)
 	if err != nil {
@@ -56,36 +130,9 @@ func (p *ReadabilityProcessor) Process(article *models.Article, opts *Options) e
 		return err
 	}
 
-	content := result.Content
-	if opts != nil {
-		if opts.MinContentLength > 0 && len(content) < opts.MinContentLength {
-			return ErrContentProcessingFailed
-		}
-
-		if opts.MaxContentLength > 0 && len(content) > opts.MaxContentLength {
-			content = content[:opts.MaxContentLength]
-		}
-
-		if !opts.IncludeImages {
-			content, err = removeHTMLTags(content, "img")
-			if err != nil {
-				log.Printf("error removing images: %v; using original content", err)
-			}
-		}
-
-		if !opts.IncludeTables {
-			content, err = removeHTMLTags(content, "table")
-			if err != nil {
-				log.Printf("error removing tables: %v; using original content", err)
-			}
-		}
-
-		if !opts.IncludeVideos {
-			content, err = removeHTMLTags(content, "video", "iframe")
-			if err != nil {
-				log.Printf("error removing videos: %v; using original content", err)
-			}
-		}
+	content, err := applyContentOptions(result.Content, opts)
+	if err != nil {
+		return err
 	}
 
 	article.Content = content
@@ -102,9 +149,72 @@ func (p *ReadabilityProcessor) Process(article *models.Article, opts *Options) e
 		article.Summary = result.Excerpt
 	}
 
+	if article.PublishedAt.IsZero() && result.PublishedTime != nil {
+		article.PublishedAt = *result.PublishedTime
+	}
+
+	details := map[string]string{
+		MetadataSiteName: result.SiteName,
+		MetadataImage:    result.Image,
+		MetadataFavicon:  result.Favicon,
+	}
+	if models.Language(article) == "" {
+		details[models.MetadataLanguage] = models.PrimaryLanguage(result.Language)
+	}
+	for key, value := range details {
+		if value == "" {
+			continue
+		}
+		if article.Metadata == nil {
+			article.Metadata = make(map[string]any)
+		}
+		article.Metadata[key] = value
+	}
+
 	return nil
 }
 
+// applyContentOptions checks extracted content against the minimum length and
+// trims it to the maximum length, and removes images, tables and videos if
+// they are disabled in opts.
+func applyContentOptions(content string, opts *Options) (string, error) {
+	if opts == nil {
+		return content, nil
+	}
+
+	if opts.MinContentLength > 0 && len(content) < opts.MinContentLength {
+		return "", ErrContentProcessingFailed
+	}
+
+	if opts.MaxContentLength > 0 && len(content) > opts.MaxContentLength {
+		content = content[:opts.MaxContentLength]
+	}
+
+	var err error
+	if !opts.IncludeImages {
+		content, err = removeHTMLTags(content, "img")
+		if err != nil {
+			log.Printf("error removing images: %v; using original content", err)
+		}
+	}
+
+	if !opts.IncludeTables {
+		content, err = removeHTMLTags(content, "table")
+		if err != nil {
+			log.Printf("error removing tables: %v; using original content", err)
+		}
+	}
+
+	if !opts.IncludeVideos {
+		content, err = removeHTMLTags(content, "video", "iframe")
+		if err != nil {
+			log.Printf("error removing videos: %v; using original content", err)
+		}
+	}
+
+	return content, nil
+}
+
 // removeHTMLTags removes all occurrences of the specified HTML tags from the content.
 func removeHTMLTags(content string, tagNames ...string) (string, error) {
 	doc, err := html.Parse(strings.NewReader(content))
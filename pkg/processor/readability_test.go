@@ -3,6 +3,7 @@ package processor
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shrik450/dijester/pkg/models"
 )
@@ -177,3 +178,106 @@ func TestReadabilityProcessor_Process_ExtractsMetadata(t *testing.T) {
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+func TestReadabilityProcessor_Process_PageDetails(t *testing.T) {
+	page := `<html lang="en-GB"><head>
+		<title>Page Title</title>
+		<meta property="og:site_name" content="Example News">
+		<meta property="og:image" content="https://example.com/lead.jpg">
+		<meta property="article:published_time" content="2024-03-01T10:00:00Z">
+		<link rel="icon" href="/favicon.png">
+		<script type="application/ld+json">
+			{"@context": "https://schema.org", "@type": "NewsArticle", "headline": "JSON-LD Title"}
+		</script>
+	</head><body><article>
+		<p class="lede keep">` + strings.Repeat("A paragraph of article text. ", 10) + `</p>
+	</article></body></html>`
+
+	tests := []struct {
+		name      string
+		options   map[string]any
+		wantTitle string
+		wantClass string
+	}{
+		{
+			name:      "defaults",
+			wantTitle: "JSON-LD Title",
+		},
+		{
+			name: "parser options",
+			options: map[string]any{
+				"disable_jsonld":      true,
+				"char_threshold":      int64(100),
+				"n_top_candidates":    int64(3),
+				"max_elems_to_parse":  int64(1000),
+				"classes_to_preserve": []any{"keep"},
+			},
+			wantTitle: "Page Title",
+			wantClass: `class="keep"`,
+		},
+		{
+			name:      "keep classes",
+			options:   map[string]any{"keep_classes": true},
+			wantTitle: "JSON-LD Title",
+			wantClass: `class="lede keep"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{URL: "https://example.com/story", Content: page}
+			opts := DefaultOptions()
+			opts.AdditionalOptions = tt.options
+
+			if err := NewReadabilityProcessor().Process(article, &opts); err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			if article.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", article.Title, tt.wantTitle)
+			}
+			if tt.wantClass != "" && !strings.Contains(article.Content, tt.wantClass) {
+				t.Errorf("content should contain %q, got:\n%s", tt.wantClass, article.Content)
+			}
+			if article.PublishedAt.Format("2006-01-02") != "2024-03-01" {
+				t.Errorf("PublishedAt = %v, want the page's published time", article.PublishedAt)
+			}
+
+			want := map[string]any{
+				MetadataSiteName:        "Example News",
+				MetadataImage:           "https://example.com/lead.jpg",
+				MetadataFavicon:         "https://example.com/favicon.png",
+				models.MetadataLanguage: "en",
+			}
+			for key, value := range want {
+				if article.Metadata[key] != value {
+					t.Errorf("Metadata[%q] = %v, want %v", key, article.Metadata[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestReadabilityProcessor_Process_KeepsExistingDetails(t *testing.T) {
+	published := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	article := &models.Article{
+		URL:         "https://example.com/story",
+		PublishedAt: published,
+		Metadata:    map[string]any{models.MetadataLanguage: "de"},
+		Content: `<html lang="en"><head>
+			<meta property="article:published_time" content="2024-03-01T10:00:00Z">
+		</head><body><p>` + strings.Repeat("Some text. ", 20) + `</p></body></html>`,
+	}
+	opts := DefaultOptions()
+	opts.MinContentLength = 0
+
+	if err := NewReadabilityProcessor().Process(article, &opts); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	if !article.PublishedAt.Equal(published) {
+		t.Errorf("PublishedAt = %v, want it unchanged", article.PublishedAt)
+	}
+	if article.Metadata[models.MetadataLanguage] != "de" {
+		t.Errorf("language = %v, want it unchanged", article.Metadata[models.MetadataLanguage])
+	}
+}
@@ -2,39 +2,296 @@ package processor
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
 
 	"github.com/shrik450/dijester/pkg/models"
 )
 
+// Built-in sanitizer policies.
+const (
+	// PolicyStrict removes all HTML, leaving only text
+	PolicyStrict = "strict"
+
+	// PolicyUGC allows the HTML commonly found in user generated content,
+	// plus images embedded as data URIs
+	PolicyUGC = "ugc"
+
+	// PolicyEPUBSafe only allows the HTML that EPUB readers render reliably
+	PolicyEPUBSafe = "epub-safe"
+)
+
+// allAttributes is the key of attribute tables for attributes on any
+// element.
+const allAttributes = "*"
+
+// SanitizerPolicy configures which elements and attributes the sanitizer
+// keeps. Elements and attributes that aren't allowed are removed, keeping the
+// text inside removed elements.
+type SanitizerPolicy struct {
+	// Base is the built-in policy this policy extends: "strict", "ugc" or
+	// "epub-safe". Empty means "ugc".
+	Base string `toml:"base"`
+
+	// AllowElements are elements to allow on top of the base policy
+	AllowElements []string `toml:"allow_elements"`
+
+	// AllowAttributes maps elements to attributes to allow on them, on top
+	// of the base policy. Attributes under "*" are allowed on any element.
+	AllowAttributes map[string][]string `toml:"allow_attributes"`
+
+	// DenyElements are elements to remove even if the base policy allows
+	// them
+	DenyElements []string `toml:"deny_elements"`
+
+	// DenyAttributes maps elements to attributes to remove from them even if
+	// the base policy allows them. Attributes under "*" are removed from any
+	// element.
+	DenyAttributes map[string][]string `toml:"deny_attributes"`
+}
+
+// Validate checks that the policy's base is a built-in policy.
+func (p SanitizerPolicy) Validate() error {
+	switch p.Base {
+	case "", PolicyStrict, PolicyUGC, PolicyEPUBSafe:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"invalid base %q: must be one of %q, %q or %q",
+		p.Base,
+		PolicyStrict,
+		PolicyUGC,
+		PolicyEPUBSafe,
+	)
+}
+
+// extend returns a copy of the policy with other's elements and attributes
+// added. Other's base is ignored.
+func (p SanitizerPolicy) extend(other SanitizerPolicy) SanitizerPolicy {
+	return SanitizerPolicy{
+		Base:            p.Base,
+		AllowElements:   slices.Concat(p.AllowElements, other.AllowElements),
+		AllowAttributes: mergeAttributes(p.AllowAttributes, other.AllowAttributes),
+		DenyElements:    slices.Concat(p.DenyElements, other.DenyElements),
+		DenyAttributes:  mergeAttributes(p.DenyAttributes, other.DenyAttributes),
+	}
+}
+
+// compile builds the bluemonday policy for the allowed elements and
+// attributes.
+func (p SanitizerPolicy) compile() (*bluemonday.Policy, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	var policy *bluemonday.Policy
+	switch p.Base {
+	case PolicyStrict:
+		policy = bluemonday.StrictPolicy()
+	case PolicyEPUBSafe:
+		policy = epubSafePolicy()
+	default:
+		policy = bluemonday.UGCPolicy()
+		policy.AllowDataURIImages()
+	}
+
+	// Elements bluemonday doesn't know, like MathML's, are otherwise only
+	// allowed with attributes.
+	if len(p.AllowElements) > 0 {
+		policy.AllowNoAttrs().OnElements(p.AllowElements...)
+	}
+	for element, attrs := range p.AllowAttributes {
+		if len(attrs) == 0 {
+			continue
+		}
+		if element == allAttributes {
+			policy.AllowAttrs(attrs...).Globally()
+		} else {
+			policy.AllowAttrs(attrs...).OnElements(element)
+		}
+	}
+
+	return policy, nil
+}
+
+// epubSafePolicy returns a policy allowing only the HTML that EPUB readers
+// render reliably: text formatting, headings, lists, tables, figures, links
+// and images, including images embedded as data URIs. Styles, classes,
+// embedded media and forms are removed.
+func epubSafePolicy() *bluemonday.Policy {
+	policy := bluemonday.NewPolicy()
+	policy.AllowStandardURLs()
+	policy.AllowStandardAttributes()
+	policy.AllowImages()
+	policy.AllowDataURIImages()
+	policy.AllowLists()
+	policy.AllowTables()
+
+	policy.AllowAttrs("href").OnElements("a")
+	policy.AllowAttrs("cite").OnElements("blockquote", "q", "del", "ins")
+	policy.AllowElements(
+		"abbr", "article", "aside", "b", "blockquote", "br", "cite", "code", "dd",
+		"del", "dfn", "div", "dl", "dt", "em", "figcaption", "figure", "footer",
+		"h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "i", "ins", "kbd",
+		"mark", "p", "pre", "q", "rp", "rt", "ruby", "s", "samp", "section",
+		"small", "span", "strong", "sub", "sup", "u", "var",
+	)
+
+	return policy
+}
+
+// mergeAttributes returns the attributes of a and b for each element.
+func mergeAttributes(a, b map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(a)+len(b))
+	for element, attrs := range a {
+		merged[element] = slices.Clone(attrs)
+	}
+	for element, attrs := range b {
+		merged[element] = append(merged[element], attrs...)
+	}
+	return merged
+}
+
 // SanitizerProcessor is a processor that sanitizes HTML content.
-type SanitizerProcessor struct{}
+type SanitizerProcessor struct {
+	policies map[string]SanitizerPolicy
+}
 
 // NewSanitizerProcessor creates a new instance of SanitizerProcessor.
 func NewSanitizerProcessor() *SanitizerProcessor {
 	return &SanitizerProcessor{}
 }
 
+// NewSanitizerProcessorWithPolicies creates a new instance of
+// SanitizerProcessor that can use the given custom policies by name.
+func NewSanitizerProcessorWithPolicies(policies map[string]SanitizerPolicy) *SanitizerProcessor {
+	return &SanitizerProcessor{policies: policies}
+}
+
 func (s *SanitizerProcessor) Name() string {
 	return "sanitizer"
 }
 
-// Process sanitizes the HTML content of the article.
+// sanitizerOptions holds the additional options of the sanitizer processor.
+type sanitizerOptions struct {
+	Policy          string              `option:"policy"`
+	AllowElements   []string            `option:"allow_elements"`
+	AllowAttributes map[string][]string `option:"allow_attributes"`
+	DenyElements    []string            `option:"deny_elements"`
+	DenyAttributes  map[string][]string `option:"deny_attributes"`
+
+	// policies are the custom policies Policy can name
+	policies map[string]SanitizerPolicy
+
+	policy   SanitizerPolicy
+	compiled *bluemonday.Policy
+}
+
+// check compiles the named policy, extended by the other options.
+func (o *sanitizerOptions) check() error {
+	policy, ok := o.policies[o.Policy]
+	if !ok {
+		switch o.Policy {
+		case PolicyStrict, PolicyUGC, PolicyEPUBSafe:
+			policy = SanitizerPolicy{Base: o.Policy}
+		default:
+			return fmt.Errorf("unknown sanitizer policy %q", o.Policy)
+		}
+	}
+
+	o.policy = policy.extend(SanitizerPolicy{
+		AllowElements:   o.AllowElements,
+		AllowAttributes: o.AllowAttributes,
+		DenyElements:    o.DenyElements,
+		DenyAttributes:  o.DenyAttributes,
+	})
+
+	var err error
+	o.compiled, err = o.policy.compile()
+	return err
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *SanitizerProcessor) NewOptions() any {
+	return &sanitizerOptions{Policy: PolicyUGC, policies: p.policies}
+}
+
+// Process sanitizes the HTML content of the article with the policy named by
+// the "policy" additional option: "ugc" (the default), "strict", "epub-safe"
+// or a custom policy. The "allow_elements", "allow_attributes",
+// "deny_elements" and "deny_attributes" additional options extend it, like
+// the fields of SanitizerPolicy.
 func (p *SanitizerProcessor) Process(article *models.Article, opts *Options) error {
 	if article == nil {
 		return errors.New("article cannot be nil")
 	}
 
-	policy := bluemonday.UGCPolicy()
-	policy.AllowDataURIImages()
+	options, err := processorOptions[sanitizerOptions](p, opts)
+	if err != nil {
+		return err
+	}
 
 	if article.Content == "" {
 		return nil
 	}
 
-	sanitizedContent := policy.Sanitize(article.Content)
+	sanitizedContent := options.compiled.Sanitize(article.Content)
+	sanitizedContent = applyDenyLists(sanitizedContent, options.policy)
 
 	article.Content = sanitizedContent
 	return nil
 }
+
+// applyDenyLists removes the policy's denied elements, keeping their content,
+// and denied attributes from sanitized content.
+func applyDenyLists(content string, policy SanitizerPolicy) string {
+	if len(policy.DenyElements) == 0 && len(policy.DenyAttributes) == 0 {
+		return content
+	}
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	denied := make(map[string]bool, len(policy.DenyElements))
+	for _, element := range policy.DenyElements {
+		denied[strings.ToLower(element)] = true
+	}
+	deniedAttrs := make(map[string][]string, len(policy.DenyAttributes))
+	for element, attrs := range policy.DenyAttributes {
+		deniedAttrs[strings.ToLower(element)] = attrs
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			traverse(c)
+
+			if c.Type == html.ElementNode {
+				attrs := slices.Concat(deniedAttrs[allAttributes], deniedAttrs[c.Data])
+				for _, attr := range attrs {
+					removeAttr(c, strings.ToLower(attr))
+				}
+
+				if denied[c.Data] {
+					for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+						c.RemoveChild(gc)
+						n.InsertBefore(gc, c)
+					}
+					n.RemoveChild(c)
+				}
+			}
+			c = next
+		}
+	}
+	traverse(doc)
+
+	return renderContent(doc, content)
+}
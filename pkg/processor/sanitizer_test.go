@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestSanitizerProcessor_Process(t *testing.T) {
+	content := `<figure class="hero" style="float: left">` +
+		`<img src="data:image/png;base64,AAAA" alt="A">` +
+		`<figcaption>Caption</figcaption></figure>` +
+		`<p title="intro">Hello <a href="https://example.com" target="_blank">link</a></p>` +
+		`<math><mi>x</mi></math><iframe src="https://video.example.com/embed"></iframe>` +
+		`<script>alert(1)</script>`
+
+	policies := map[string]SanitizerPolicy{
+		"science": {
+			Base:          PolicyEPUBSafe,
+			AllowElements: []string{"math", "mi"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options map[string]any
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "ugc by default",
+			want:    []string{`<img src="data:image/png;base64,AAAA"`, `title="intro"`, "<figure>"},
+			notWant: []string{"<math", "<iframe", "alert(1)", "style="},
+		},
+		{
+			name:    "strict",
+			options: map[string]any{"policy": "strict"},
+			want:    []string{"CaptionHello link"},
+			notWant: []string{"<p", "<img"},
+		},
+		{
+			name:    "epub-safe",
+			options: map[string]any{"policy": "epub-safe"},
+			want:    []string{"<figure>", "<figcaption>Caption</figcaption>", `title="intro"`},
+			notWant: []string{"<math", "<iframe", "class=", "style=", "target="},
+		},
+		{
+			name:    "custom policy",
+			options: map[string]any{"policy": "science"},
+			want:    []string{"<figure>", "<math><mi>x</mi></math>"},
+		},
+		{
+			name: "allow and deny overrides",
+			options: map[string]any{
+				"policy":           "science",
+				"allow_elements":   []any{"iframe"},
+				"allow_attributes": map[string]any{"iframe": []any{"src"}},
+				"deny_elements":    []any{"figure", "math"},
+				"deny_attributes":  map[string]any{"*": []any{"title"}},
+			},
+			want: []string{
+				`<iframe src="https://video.example.com/embed"></iframe>`,
+				"<figcaption>Caption</figcaption>",
+				"<mi>x</mi>",
+				"<p>Hello",
+			},
+			notWant: []string{"<figure", "<math", "title="},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{Content: content}
+			opts := DefaultOptions()
+			opts.AdditionalOptions = tt.options
+
+			err := NewSanitizerProcessorWithPolicies(policies).Process(article, &opts)
+			if err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(article.Content, want) {
+					t.Errorf("content should contain %q, got:\n%s", want, article.Content)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(article.Content, notWant) {
+					t.Errorf("content should not contain %q, got:\n%s", notWant, article.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestInitializeProcessors_SanitizerPolicies(t *testing.T) {
+	global := ProcessorConfig{
+		SanitizerPolicies: map[string]SanitizerPolicy{
+			"figures": {Base: PolicyEPUBSafe, DenyElements: []string{"img"}},
+		},
+	}
+	source := ProcessorConfig{
+		Processors: []string{"sanitizer"},
+		ProcessorConfigs: map[string]any{
+			"sanitizer": map[string]any{
+				"additional_options": map[string]any{"policy": "figures"},
+			},
+		},
+	}
+
+	procs, opts, err := InitializeProcessors(source.Inherit(global))
+	if err != nil {
+		t.Fatalf("InitializeProcessors() returned error: %v", err)
+	}
+
+	article := &models.Article{Content: `<figure><img src="https://example.com/a.png"></figure>`}
+	if err := procs[0].Process(article, &opts[0]); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	if article.Content != "<figure></figure>" {
+		t.Errorf("Content = %q, want the global policy applied", article.Content)
+	}
+
+	if _, _, err := InitializeProcessors(source); err == nil {
+		t.Error("InitializeProcessors() with an unknown policy expected error, got nil")
+	}
+}
+
+func TestProcessorConfig_Validate(t *testing.T) {
+	for name, policy := range map[string]SanitizerPolicy{
+		"ugc":     {},
+		"unknown": {Base: "figures"},
+	} {
+		cfg := ProcessorConfig{SanitizerPolicies: map[string]SanitizerPolicy{name: policy}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() with policy %q expected error, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package processor
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// OptionType is the type of a processor option's value.
+type OptionType int
+
+const (
+	// OptionInt is an integer
+	OptionInt OptionType = iota
+
+	// OptionFloat is a number, integer or not
+	OptionFloat
+
+	// OptionBool is a boolean
+	OptionBool
+
+	// OptionString is a string
+	OptionString
+
+	// OptionStringList is a list of strings
+	OptionStringList
+
+	// OptionStringTable is a table of strings, decoded to a
+	// map[string]string
+	OptionStringTable
+
+	// OptionStringListTable is a table of lists of strings, e.g. tags to
+	// words
+	OptionStringListTable
+
+	// OptionTableList is a list of tables of strings
+	OptionTableList
+)
+
+// String returns the type's name, as used in error messages.
+func (t OptionType) String() string {
+	switch t {
+	case OptionInt:
+		return "an integer"
+	case OptionFloat:
+		return "a number"
+	case OptionBool:
+		return "a boolean"
+	case OptionString:
+		return "a string"
+	case OptionStringList:
+		return "a list of strings"
+	case OptionStringTable:
+		return "a table of strings"
+	case OptionStringListTable:
+		return "a table of lists of strings"
+	case OptionTableList:
+		return "a list of tables of strings"
+	}
+	return fmt.Sprintf("OptionType(%d)", int(t))
+}
+
+// Schema declares the additional options a processor accepts and their
+// types.
+type Schema map[string]OptionType
+
+// Configurable is implemented by processors that accept additional options.
+// Processors that don't implement it accept none.
+type Configurable interface {
+	// NewOptions returns a pointer to a struct holding the processor's
+	// default additional options. Each field tagged `option:"name"` is an
+	// option, whose type follows from the field's type: int, float64, bool,
+	// string, []string, map[string]string, map[string][]string or
+	// []map[string]string. Options of embedded structs are included, e.g.
+	// for processors that pass their options on to other processors.
+	NewOptions() any
+}
+
+// optionsChecker is implemented by additional options that check their
+// values once decoded, and prepare them for processing, e.g. by compiling
+// selectors or patterns.
+type optionsChecker interface {
+	check() error
+}
+
+// optionTypes maps the types of options struct fields to the types of their
+// options.
+var optionTypes = map[reflect.Type]OptionType{
+	reflect.TypeFor[int]():                 OptionInt,
+	reflect.TypeFor[float64]():             OptionFloat,
+	reflect.TypeFor[bool]():                OptionBool,
+	reflect.TypeFor[string]():              OptionString,
+	reflect.TypeFor[[]string]():            OptionStringList,
+	reflect.TypeFor[map[string]string]():   OptionStringTable,
+	reflect.TypeFor[map[string][]string](): OptionStringListTable,
+	reflect.TypeFor[[]map[string]string](): OptionTableList,
+}
+
+// optionFields returns the fields of an options struct by option name.
+func optionFields(options any) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+
+	var collect func(v reflect.Value)
+	collect = func(v reflect.Value) {
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				collect(v.Field(i))
+				continue
+			}
+
+			name := field.Tag.Get("option")
+			if name == "" {
+				continue
+			}
+			if _, ok := optionTypes[field.Type]; !ok {
+				panic(fmt.Sprintf("option %s has unsupported type %s", name, field.Type))
+			}
+			fields[name] = v.Field(i)
+		}
+	}
+	collect(reflect.ValueOf(options).Elem())
+
+	return fields
+}
+
+// schemaOf returns the schema of an options struct.
+func schemaOf(options any) Schema {
+	schema := make(Schema)
+	for name, field := range optionFields(options) {
+		schema[name] = optionTypes[field.Type()]
+	}
+	return schema
+}
+
+// decodeOptions decodes additional options into an options struct, keeping
+// the defaults of options that aren't set, and checks their values.
+func decodeOptions(additionalOptions map[string]any, options any) error {
+	fields := optionFields(options)
+	decoded, err := schemaOf(options).Decode(additionalOptions)
+	if err != nil {
+		return err
+	}
+	for name, v := range decoded {
+		fields[name].Set(reflect.ValueOf(v))
+	}
+
+	if checker, ok := options.(optionsChecker); ok {
+		return checker.check()
+	}
+	return nil
+}
+
+// processorOptions returns a processor's decoded additional options, as
+// decoded by DecodeOptions. Options that weren't, e.g. ones built in tests,
+// are decoded now.
+func processorOptions[T any](p Configurable, opts *Options) (*T, error) {
+	if opts != nil {
+		if options, ok := opts.decoded.(*T); ok {
+			return options, nil
+		}
+	}
+
+	options := p.NewOptions().(*T)
+	var additionalOptions map[string]any
+	if opts != nil {
+		additionalOptions = opts.AdditionalOptions
+	}
+	if err := decodeOptions(additionalOptions, options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// withOptions returns a copy of opts holding already decoded additional
+// options, for processors that run other processors with the options they
+// embed.
+func withOptions(opts *Options, decoded any) *Options {
+	// These settings change nothing, like nil options.
+	inner := Options{IncludeImages: true, IncludeTables: true, IncludeVideos: true}
+	if opts != nil {
+		inner = *opts
+	}
+	inner.decoded = decoded
+	return &inner
+}
+
+// Decode checks that options only has keys the schema declares, with values
+// of the declared types, and returns them converted to the types of options
+// struct fields: int, float64, bool, string, []string, map[string]string,
+// map[string][]string and []map[string]string.
+func (s Schema) Decode(options map[string]any) (map[string]any, error) {
+	decoded := make(map[string]any, len(options))
+
+	// Check keys in order so the same config always reports the same error.
+	for _, key := range slices.Sorted(maps.Keys(options)) {
+		typ, ok := s[key]
+		if !ok {
+			return nil, unknownOptionError(key, s)
+		}
+
+		v, ok := decodeOption(options[key], typ)
+		if !ok {
+			return nil, fmt.Errorf("%s must be %s, got %s", key, typ, describeValue(options[key]))
+		}
+		decoded[key] = v
+	}
+
+	return decoded, nil
+}
+
+// decodeOption converts a configuration value to the type processors expect
+// for typ, reporting whether it has that type.
+func decodeOption(v any, typ OptionType) (any, bool) {
+	switch typ {
+	case OptionInt:
+		switch v := v.(type) {
+		case int:
+			return v, true
+		case int64:
+			return int(v), true
+		}
+
+	case OptionFloat:
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case int:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		}
+
+	case OptionBool:
+		v, ok := v.(bool)
+		return v, ok
+
+	case OptionString:
+		v, ok := v.(string)
+		return v, ok
+
+	case OptionStringList:
+		return stringList(v)
+
+	case OptionStringTable:
+		return stringTable(v)
+
+	case OptionStringListTable:
+		table, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		decoded := make(map[string][]string, len(table))
+		for key, item := range table {
+			list, ok := stringList(item)
+			if !ok {
+				return nil, false
+			}
+			decoded[key] = list
+		}
+		return decoded, true
+
+	case OptionTableList:
+		var items []any
+		switch v := v.(type) {
+		case []map[string]any:
+			for _, item := range v {
+				items = append(items, item)
+			}
+		case []any:
+			items = v
+		default:
+			return nil, false
+		}
+		tables := make([]map[string]string, len(items))
+		for i, item := range items {
+			table, ok := stringTable(item)
+			if !ok {
+				return nil, false
+			}
+			tables[i] = table
+		}
+		return tables, true
+	}
+
+	return nil, false
+}
+
+// stringList converts a configuration value to a list of strings.
+func stringList(v any) ([]string, bool) {
+	switch v := v.(type) {
+	case []string:
+		return v, true
+	case []any:
+		list := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			list[i] = s
+		}
+		return list, true
+	}
+
+	return nil, false
+}
+
+// stringTable converts a configuration value to a table of strings.
+func stringTable(v any) (map[string]string, bool) {
+	switch v := v.(type) {
+	case map[string]string:
+		return v, true
+	case map[string]any:
+		table := make(map[string]string, len(v))
+		for key, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			table[key] = s
+		}
+		return table, true
+	}
+
+	return nil, false
+}
+
+// describeValue describes a configuration value for error messages.
+func describeValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]any:
+		return "a table"
+	case []any, []string, []map[string]any:
+		return fmt.Sprintf("a list %v", v)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// unknownOptionError reports an option a schema doesn't declare, listing the
+// options it does.
+func unknownOptionError(key string, s Schema) error {
+	if len(s) == 0 {
+		return fmt.Errorf("unknown option %q: the processor has no options", key)
+	}
+	known := slices.Sorted(maps.Keys(s))
+	return fmt.Errorf("unknown option %q, expected one of: %s", key, strings.Join(known, ", "))
+}
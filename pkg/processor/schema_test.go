@@ -0,0 +1,252 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchema_Decode(t *testing.T) {
+	schema := Schema{
+		"count":    OptionInt,
+		"ratio":    OptionFloat,
+		"enabled":  OptionBool,
+		"name":     OptionString,
+		"tags":     OptionStringList,
+		"rules":    OptionStringListTable,
+		"replaces": OptionTableList,
+	}
+
+	decoded, err := schema.Decode(map[string]any{
+		"count":    int64(3),
+		"ratio":    int64(1),
+		"enabled":  true,
+		"name":     "x",
+		"tags":     []any{"a", "b"},
+		"rules":    map[string]any{"go": []any{"golang"}},
+		"replaces": []any{map[string]any{"pattern": "a"}},
+	})
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+
+	want := map[string]any{
+		"count":    3,
+		"ratio":    1.0,
+		"enabled":  true,
+		"name":     "x",
+		"tags":     []string{"a", "b"},
+		"rules":    map[string][]string{"go": {"golang"}},
+		"replaces": []map[string]string{{"pattern": "a"}},
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("Decode() = %#v, want %#v", decoded, want)
+	}
+}
+
+func TestSchema_Decode_Errors(t *testing.T) {
+	schema := Schema{
+		"count":    OptionInt,
+		"tags":     OptionStringList,
+		"rules":    OptionStringListTable,
+		"replaces": OptionTableList,
+	}
+
+	tests := []struct {
+		options map[string]any
+		want    string
+	}{
+		{
+			options: map[string]any{"count": 1.5},
+			want:    "count must be an integer, got 1.5",
+		},
+		{
+			options: map[string]any{"tags": []any{"a", int64(1)}},
+			want:    "tags must be a list of strings",
+		},
+		{
+			options: map[string]any{"rules": map[string]any{"go": "golang"}},
+			want:    "rules must be a table of lists of strings",
+		},
+		{
+			options: map[string]any{"replaces": []any{map[string]any{"pattern": int64(1)}}},
+			want:    "replaces must be a list of tables of strings",
+		},
+		{
+			options: map[string]any{"cuont": int64(1)},
+			want:    `unknown option "cuont", expected one of: count, replaces, rules, tags`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			_, err := schema.Decode(tt.options)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Decode() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitializeProcessors_Strict(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			config: map[string]any{"min_content_length": int64(50), "include_images": false},
+		},
+		{
+			name:    "float for an integer setting",
+			config:  map[string]any{"min_content_length": 50.0},
+			wantErr: "processing options for readability: min_content_length must be an integer",
+		},
+		{
+			name:    "unknown setting",
+			config:  map[string]any{"include_image": false},
+			wantErr: `unknown option "include_image"`,
+		},
+		{
+			name: "unknown additional option",
+			config: map[string]any{
+				"additional_options": map[string]any{"char_treshold": int64(1)},
+			},
+			wantErr: `additional_options: unknown option "char_treshold"`,
+		},
+		{
+			name: "additional option of the wrong type",
+			config: map[string]any{
+				"additional_options": map[string]any{"keep_classes": "yes"},
+			},
+			wantErr: `additional_options: keep_classes must be a boolean, got "yes"`,
+		},
+		{
+			name: "invalid additional option value",
+			config: map[string]any{
+				"additional_options": map[string]any{"n_top_candidates": int64(0)},
+			},
+			wantErr: "additional_options: n_top_candidates must be positive, got 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ProcessorConfig{
+				Processors:       []string{"readability"},
+				ProcessorConfigs: map[string]any{"readability": tt.config},
+			}
+			_, opts, err := InitializeProcessors(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("InitializeProcessors() returned error: %v", err)
+				}
+				if opts[0].MinContentLength != 50 || opts[0].IncludeImages {
+					t.Errorf("options = %+v, want the configured settings", opts[0])
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("InitializeProcessors() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcessorConfig_Validate_ProcessorConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProcessorConfig
+	}{
+		{
+			name: "unknown processor",
+			cfg:  ProcessorConfig{Processors: []string{"readabilty"}},
+		},
+		{
+			name: "config for an unused processor",
+			cfg: ProcessorConfig{
+				ProcessorConfigs: map[string]any{
+					"stats": map[string]any{
+						"additional_options": map[string]any{"wpm": int64(200)},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid value",
+			cfg: ProcessorConfig{
+				Processors: []string{"cleanup"},
+				ProcessorConfigs: map[string]any{
+					"cleanup": map[string]any{
+						"additional_options": map[string]any{"keep_selector": "div["},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestProcessors_DeclareSchemas(t *testing.T) {
+	for _, name := range List() {
+		p, err := New(name)
+		if err != nil {
+			t.Fatalf("New(%q) returned error: %v", name, err)
+		}
+		configurable, ok := p.(Configurable)
+		if !ok {
+			t.Errorf("processor %s does not declare a schema", name)
+			continue
+		}
+
+		// Decoding panics on options of unsupported types, and the defaults
+		// must be valid unless an option is required.
+		err = decodeOptions(nil, configurable.NewOptions())
+		if err != nil && name != "exec" {
+			t.Errorf("processor %s has invalid default options: %v", name, err)
+		}
+	}
+}
+
+func TestDecodeOptions_Typed(t *testing.T) {
+	opts, err := DecodeOptions(NewFallbackProcessor(), map[string]any{
+		"additional_options": map[string]any{
+			"strategies":          []any{"feed"},
+			"char_threshold":      int64(100),
+			"classes_to_preserve": []any{"keep"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DecodeOptions() returned error: %v", err)
+	}
+
+	options, ok := opts.decoded.(*fallbackOptions)
+	if !ok {
+		t.Fatalf("decoded options = %T, want *fallbackOptions", opts.decoded)
+	}
+	if !reflect.DeepEqual(options.Strategies, []string{"feed"}) {
+		t.Errorf("Strategies = %v, want [feed]", options.Strategies)
+	}
+	if options.MinWords != defaultFallbackMinWords {
+		t.Errorf("MinWords = %d, want the default %d", options.MinWords, defaultFallbackMinWords)
+	}
+	classes := options.ClassesToPreserve
+	if options.CharThreshold != 100 || !reflect.DeepEqual(classes, []string{"keep"}) {
+		t.Errorf("readability options = %+v, want the configured ones", options.readabilityOptions)
+	}
+
+	// Processors use the decoded options rather than decoding them again.
+	opts.AdditionalOptions = map[string]any{"strategies": "invalid"}
+	got, err := processorOptions[fallbackOptions](NewFallbackProcessor(), &opts)
+	if err != nil || got != options {
+		t.Errorf("processorOptions() = %p, %v, want the decoded options %p", got, err, options)
+	}
+}
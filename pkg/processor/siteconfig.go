@@ -0,0 +1,520 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/araddon/dateparse"
+	"golang.org/x/net/html"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// Metadata keys holding links found by a site config's single_page_link and
+// next_page_link rules.
+const (
+	MetadataSinglePageURL = "single_page_url"
+	MetadataNextPageURL   = "next_page_url"
+)
+
+// SiteConfig holds the extraction rules for a site, in the format of the
+// ftr-site-config project used by Five Filters' Full-Text RSS. XPath
+// expressions are tried in order until one matches.
+type SiteConfig struct {
+	// Title, Body, Author and Date are XPath expressions selecting the
+	// article's parts
+	Title  []string
+	Body   []string
+	Author []string
+	Date   []string
+
+	// Strip are XPath expressions selecting elements to remove
+	Strip []string
+
+	// StripIDOrClass removes elements whose id or class contains any of these
+	StripIDOrClass []string
+
+	// StripImageSrc removes images whose src contains any of these
+	StripImageSrc []string
+
+	// SinglePageLink are XPath expressions selecting a link to a single page
+	// version of the article
+	SinglePageLink []string
+
+	// NextPageLink are XPath expressions selecting a link to the article's
+	// next page
+	NextPageLink []string
+
+	// Replacements are applied to the page's HTML before it is parsed
+	Replacements []StringReplacement
+
+	// AutodetectOnFailure falls back to readability if no body rule matches
+	AutodetectOnFailure bool
+
+	// TestURLs are example article URLs the rules were written for
+	TestURLs []string
+}
+
+// StringReplacement replaces every occurrence of Find with Replace.
+type StringReplacement struct {
+	Find    string
+	Replace string
+}
+
+// ParseSiteConfig parses a site config file. Each line holds a
+// "directive: value" pair, and lines starting with "#" are comments.
+// Unsupported directives, such as "tidy" or "http_header(...)", are ignored.
+func ParseSiteConfig(r io.Reader) (*SiteConfig, error) {
+	cfg := &SiteConfig{AutodetectOnFailure: true}
+	var finds, replaces []string
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// replace_string(find): replace is a shorthand for a find_string and
+		// replace_string pair. find may itself contain colons.
+		if rest, ok := strings.CutPrefix(line, "replace_string("); ok {
+			find, replace, ok := strings.Cut(rest, "):")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated replace_string(", lineNum)
+			}
+			cfg.Replacements = append(
+				cfg.Replacements,
+				StringReplacement{Find: find, Replace: strings.TrimSpace(replace)},
+			)
+			continue
+		}
+
+		directive, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"directive: value\", got %q", lineNum, line)
+		}
+		directive = strings.TrimSpace(directive)
+		value = strings.TrimSpace(value)
+
+		switch directive {
+		case "title":
+			cfg.Title = append(cfg.Title, value)
+		case "body":
+			cfg.Body = append(cfg.Body, value)
+		case "author":
+			cfg.Author = append(cfg.Author, value)
+		case "date":
+			cfg.Date = append(cfg.Date, value)
+		case "strip":
+			cfg.Strip = append(cfg.Strip, value)
+		case "strip_id_or_class":
+			cfg.StripIDOrClass = append(cfg.StripIDOrClass, value)
+		case "strip_image_src":
+			cfg.StripImageSrc = append(cfg.StripImageSrc, value)
+		case "single_page_link":
+			cfg.SinglePageLink = append(cfg.SinglePageLink, value)
+		case "next_page_link":
+			cfg.NextPageLink = append(cfg.NextPageLink, value)
+		case "find_string":
+			finds = append(finds, value)
+		case "replace_string":
+			replaces = append(replaces, value)
+		case "autodetect_on_failure":
+			cfg.AutodetectOnFailure = value == "yes"
+		case "test_url":
+			cfg.TestURLs = append(cfg.TestURLs, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(finds) != len(replaces) {
+		return nil, fmt.Errorf(
+			"found %d find_string and %d replace_string directives, expected pairs",
+			len(finds),
+			len(replaces),
+		)
+	}
+	for i := range finds {
+		cfg.Replacements = append(
+			cfg.Replacements,
+			StringReplacement{Find: finds[i], Replace: replaces[i]},
+		)
+	}
+
+	for _, exprs := range [][]string{
+		cfg.Title,
+		cfg.Body,
+		cfg.Author,
+		cfg.Date,
+		cfg.Strip,
+		cfg.SinglePageLink,
+		cfg.NextPageLink,
+	} {
+		for _, expr := range exprs {
+			if _, err := xpath.Compile(expr); err != nil {
+				return nil, fmt.Errorf("invalid XPath %q: %w", expr, err)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// SiteConfigs loads site configs from a directory, where each file is named
+// after the host it applies to, e.g. "example.com.txt". Files named after a
+// domain with a leading dot, e.g. ".example.com.txt", apply to all of its
+// subdomains. Configs are loaded when first needed and cached.
+type SiteConfigs struct {
+	dir string
+
+	mu    sync.Mutex
+	cache map[string]*SiteConfig
+}
+
+// NewSiteConfigs returns the site configs in dir.
+func NewSiteConfigs(dir string) *SiteConfigs {
+	return &SiteConfigs{dir: dir, cache: make(map[string]*SiteConfig)}
+}
+
+// Lookup returns the site config for a host, or nil if there is none. The
+// host itself is tried first, then the host without a leading "www.", then
+// wildcard configs for each of its parent domains.
+func (s *SiteConfigs) Lookup(host string) (*SiteConfig, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return nil, nil
+	}
+
+	names := []string{host}
+	if trimmed, ok := strings.CutPrefix(host, "www."); ok {
+		names = append(names, trimmed)
+	}
+	for domain := host; strings.Count(domain, ".") >= 1; {
+		_, domain, _ = strings.Cut(domain, ".")
+		if strings.Contains(domain, ".") {
+			names = append(names, "."+domain)
+		}
+	}
+
+	for _, name := range names {
+		cfg, err := s.load(name)
+		if err != nil || cfg != nil {
+			return cfg, err
+		}
+	}
+
+	return nil, nil
+}
+
+// load reads and caches a single site config file, returning nil if it
+// doesn't exist.
+func (s *SiteConfigs) load(name string) (*SiteConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg, ok := s.cache[name]; ok {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		s.cache[name] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := ParseSiteConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing site config %s.txt: %w", name, err)
+	}
+
+	s.cache[name] = cfg
+	return cfg, nil
+}
+
+// SiteConfigProcessor extracts content with site-specific rules, falling back
+// to readability for sites without rules.
+type SiteConfigProcessor struct {
+	readability *ReadabilityProcessor
+
+	mu      sync.Mutex
+	configs map[string]*SiteConfigs
+}
+
+// NewSiteConfigProcessor creates a new instance of SiteConfigProcessor.
+func NewSiteConfigProcessor() *SiteConfigProcessor {
+	return &SiteConfigProcessor{
+		readability: NewReadabilityProcessor(),
+		configs:     make(map[string]*SiteConfigs),
+	}
+}
+
+// Name returns the name of this processor.
+func (p *SiteConfigProcessor) Name() string {
+	return "siteconfig"
+}
+
+// siteConfigOptions holds the additional options of the siteconfig
+// processor, including those of the readability processor it falls back to.
+type siteConfigOptions struct {
+	readabilityOptions
+
+	Directory   string `option:"directory"`
+	Readability bool   `option:"readability"`
+}
+
+func newSiteConfigOptions() siteConfigOptions {
+	return siteConfigOptions{readabilityOptions: newReadabilityOptions(), Readability: true}
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *SiteConfigProcessor) NewOptions() any {
+	options := newSiteConfigOptions()
+	return &options
+}
+
+// Process extracts the article's content using the site config for its host,
+// from the directory set with the "directory" additional option. Links found
+// by the config's single_page_link and next_page_link rules are recorded in
+// the article's metadata.
+//
+// Articles without a site config, or whose config has no matching body rule
+// and allows autodetection, are extracted with readability instead. This can
+// be disabled with the "readability" additional option, to only use site
+// configs before running the readability processor separately.
+func (p *SiteConfigProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	if article.Content == "" {
+		return nil
+	}
+
+	options, err := processorOptions[siteConfigOptions](p, opts)
+	if err != nil {
+		return err
+	}
+	if options.Directory == "" {
+		return errors.New("siteconfig processor requires a directory option")
+	}
+	fallback := options.Readability
+	readabilityOpts := withOptions(opts, &options.readabilityOptions)
+
+	articleURL, err := url.Parse(article.URL)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := p.siteConfigs(options.Directory).Lookup(articleURL.Hostname())
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		if fallback {
+			return p.readability.Process(article, readabilityOpts)
+		}
+		return nil
+	}
+
+	extracted, err := cfg.Apply(article)
+	if err != nil {
+		return err
+	}
+	if !extracted && cfg.AutodetectOnFailure && fallback {
+		return p.readability.Process(article, readabilityOpts)
+	}
+	if !extracted {
+		return nil
+	}
+
+	article.Content, err = applyContentOptions(article.Content, opts)
+	return err
+}
+
+// siteConfigs returns the cached site configs for a directory.
+func (p *SiteConfigProcessor) siteConfigs(dir string) *SiteConfigs {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	configs, ok := p.configs[dir]
+	if !ok {
+		configs = NewSiteConfigs(dir)
+		p.configs[dir] = configs
+	}
+	return configs
+}
+
+// Apply applies the site config to an article: its HTML replacements and
+// strip rules are applied to the content, and the title, author and date
+// rules fill in whichever of these the article is missing. It reports whether
+// a body rule matched, in which case the content is replaced by the body;
+// otherwise it is left stripped, but otherwise whole.
+func (c *SiteConfig) Apply(article *models.Article) (bool, error) {
+	content := article.Content
+	for _, r := range c.Replacements {
+		content = strings.ReplaceAll(content, r.Find, r.Replace)
+	}
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return false, err
+	}
+
+	base, err := url.Parse(article.URL)
+	if err != nil {
+		return false, err
+	}
+
+	// Links are found before stripping, which often removes the page's
+	// navigation.
+	links := map[string][]string{
+		MetadataSinglePageURL: c.SinglePageLink,
+		MetadataNextPageURL:   c.NextPageLink,
+	}
+	for key, exprs := range links {
+		if link := firstXPathLink(doc, exprs, base); link != "" {
+			if article.Metadata == nil {
+				article.Metadata = make(map[string]any)
+			}
+			article.Metadata[key] = link
+		}
+	}
+
+	strip := append([]string{}, c.Strip...)
+	for _, s := range c.StripIDOrClass {
+		s = xpathString(s)
+		strip = append(strip, fmt.Sprintf("//*[contains(@class, %s) or contains(@id, %s)]", s, s))
+	}
+	for _, s := range c.StripImageSrc {
+		strip = append(strip, fmt.Sprintf("//img[contains(@src, %s)]", xpathString(s)))
+	}
+	for _, expr := range strip {
+		nodes, err := htmlquery.QueryAll(doc, expr)
+		if err != nil {
+			return false, fmt.Errorf("invalid XPath %q: %w", expr, err)
+		}
+		for _, n := range nodes {
+			if n.Parent != nil {
+				n.Parent.RemoveChild(n)
+			}
+		}
+	}
+
+	if article.Title == "" {
+		article.Title = firstXPathText(doc, c.Title)
+	}
+	if article.Author == "" {
+		article.Author = firstXPathText(doc, c.Author)
+	}
+	if article.PublishedAt.IsZero() {
+		if date := firstXPathText(doc, c.Date); date != "" {
+			if t, err := dateparse.ParseAny(date); err == nil {
+				article.PublishedAt = t
+			} else {
+				log.Printf("Error parsing date %q of %s: %v", date, article.URL, err)
+			}
+		}
+	}
+
+	for _, expr := range c.Body {
+		nodes, err := htmlquery.QueryAll(doc, expr)
+		if err != nil {
+			return false, fmt.Errorf("invalid XPath %q: %w", expr, err)
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+
+		var buf strings.Builder
+		for _, n := range nodes {
+			if err := html.Render(&buf, n); err != nil {
+				return false, err
+			}
+		}
+		if len(nodes) > 1 {
+			article.Content = "<div>" + buf.String() + "</div>"
+		} else {
+			article.Content = buf.String()
+		}
+		return true, nil
+	}
+
+	article.Content = renderContent(doc, content)
+	return false, nil
+}
+
+// firstXPathText returns the trimmed text of the first node matched by the
+// first of exprs that matches anything with text.
+func firstXPathText(doc *html.Node, exprs []string) string {
+	for _, expr := range exprs {
+		nodes, err := htmlquery.QueryAll(doc, expr)
+		if err != nil {
+			continue
+		}
+		for _, n := range nodes {
+			if text := strings.Join(strings.Fields(htmlquery.InnerText(n)), " "); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// firstXPathLink returns the URL of the first link matched by exprs, resolved
+// against base. Expressions can select either links or their href attributes.
+func firstXPathLink(doc *html.Node, exprs []string, base *url.URL) string {
+	for _, expr := range exprs {
+		nodes, err := htmlquery.QueryAll(doc, expr)
+		if err != nil {
+			continue
+		}
+		for _, n := range nodes {
+			href := htmlquery.SelectAttr(n, "href")
+			if n.Parent == nil && n.Type == html.ElementNode {
+				// Attributes are returned as detached elements holding their
+				// value.
+				href = htmlquery.InnerText(n)
+			}
+			href = strings.TrimSpace(href)
+			if href == "" || strings.HasPrefix(href, "#") {
+				continue
+			}
+			if ref, err := url.Parse(href); err == nil {
+				return base.ResolveReference(ref).String()
+			}
+		}
+	}
+	return ""
+}
+
+// xpathString quotes s as an XPath string literal.
+func xpathString(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+
+	parts := strings.Split(s, "'")
+	return "concat('" + strings.Join(parts, `', "'", '`) + "')"
+}
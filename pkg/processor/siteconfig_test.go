@@ -0,0 +1,192 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+const exampleSiteConfig = `# Rules for example.com
+title: //h1[@class='headline']
+body: //div[@id='story']
+body: //article
+author: //span[@class='byline']/a
+date: //time/@datetime
+strip: //div[contains(@class, 'newsletter')]
+strip_id_or_class: share
+strip_image_src: tracking.gif
+next_page_link: //a[@rel='next']/@href
+single_page_link: //a[contains(text(), 'Single page')]
+find_string: <br class="fake-para">
+replace_string: </p><p>
+replace_string(http://cdn.example.com): https://cdn.example.com
+tidy: no
+prune: no
+test_url: https://example.com/story
+`
+
+const exampleSitePage = `<html><head><title>Site</title></head><body>
+<nav><a href="/story?print=1">Single page</a></nav>
+<h1 class="headline"> A  headline </h1>
+<span class="byline">By <a href="/jane">Jane Doe</a></span>
+<time datetime="2024-03-01T10:00:00Z">March 1</time>
+<div id="story">
+	<p>First part.<br class="fake-para">Second part.</p>
+	<div class="share-buttons">Share</div>
+	<div class="newsletter box">Sign up</div>
+	<img src="http://cdn.example.com/photo.jpg"><img src="/tracking.gif">
+</div>
+<a rel="next" href="/story?page=2">Next</a>
+</body></html>`
+
+func TestParseSiteConfig(t *testing.T) {
+	cfg, err := ParseSiteConfig(strings.NewReader(exampleSiteConfig))
+	if err != nil {
+		t.Fatalf("ParseSiteConfig() returned error: %v", err)
+	}
+
+	if len(cfg.Body) != 2 || cfg.Body[1] != "//article" {
+		t.Errorf("Body = %v, want both rules in order", cfg.Body)
+	}
+	if len(cfg.Replacements) != 2 ||
+		cfg.Replacements[0].Find != "http://cdn.example.com" ||
+		cfg.Replacements[1].Replace != "</p><p>" {
+		t.Errorf("Replacements = %+v", cfg.Replacements)
+	}
+	if !cfg.AutodetectOnFailure {
+		t.Error("AutodetectOnFailure should default to true")
+	}
+
+	for _, invalid := range []string{
+		"body //div",
+		"body: //div[",
+		"find_string: a",
+		"replace_string(a: b",
+	} {
+		if _, err := ParseSiteConfig(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ParseSiteConfig(%q) expected error, got nil", invalid)
+		}
+	}
+}
+
+func TestSiteConfigs_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	writeSiteConfig(t, dir, "example.com.txt", "body: //article")
+	writeSiteConfig(t, dir, ".blogs.example.org.txt", "body: //main")
+
+	configs := NewSiteConfigs(dir)
+	tests := []struct {
+		host string
+		body string
+	}{
+		{host: "example.com", body: "//article"},
+		{host: "www.example.com", body: "//article"},
+		{host: "news.blogs.example.org", body: "//main"},
+		{host: "other.example.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			cfg, err := configs.Lookup(tt.host)
+			if err != nil {
+				t.Fatalf("Lookup() returned error: %v", err)
+			}
+			switch {
+			case tt.body == "" && cfg != nil:
+				t.Errorf("Lookup(%q) = %+v, want nil", tt.host, cfg)
+			case tt.body != "" && (cfg == nil || cfg.Body[0] != tt.body):
+				t.Errorf("Lookup(%q) = %+v, want body %q", tt.host, cfg, tt.body)
+			}
+		})
+	}
+}
+
+func TestSiteConfigProcessor_Process(t *testing.T) {
+	dir := t.TempDir()
+	writeSiteConfig(t, dir, "example.com.txt", exampleSiteConfig)
+	writeSiteConfig(t, dir, "nobody.example.net.txt", "body: //article\nstrip: //aside\n"+
+		"autodetect_on_failure: no")
+
+	opts := DefaultOptions()
+	opts.MinContentLength = 0
+	opts.AdditionalOptions = map[string]any{"directory": dir}
+	proc := NewSiteConfigProcessor()
+
+	t.Run("extracts with site config", func(t *testing.T) {
+		article := &models.Article{URL: "https://www.example.com/story", Content: exampleSitePage}
+		if err := proc.Process(article, &opts); err != nil {
+			t.Fatalf("Process() returned error: %v", err)
+		}
+
+		if article.Title != "A headline" || article.Author != "Jane Doe" {
+			t.Errorf("Title = %q, Author = %q", article.Title, article.Author)
+		}
+		if article.PublishedAt.Year() != 2024 {
+			t.Errorf("PublishedAt = %v, want date from page", article.PublishedAt)
+		}
+		nextPage := article.Metadata[MetadataNextPageURL]
+		if nextPage != "https://www.example.com/story?page=2" {
+			t.Errorf("next page URL = %v", nextPage)
+		}
+		singlePage := article.Metadata[MetadataSinglePageURL]
+		if singlePage != "https://www.example.com/story?print=1" {
+			t.Errorf("single page URL = %v", singlePage)
+		}
+
+		for _, want := range []string{
+			`<div id="story">`,
+			"<p>First part.</p><p>Second part.</p>",
+			"https://cdn.example.com/photo.jpg",
+		} {
+			if !strings.Contains(article.Content, want) {
+				t.Errorf("content should contain %q, got:\n%s", want, article.Content)
+			}
+		}
+		for _, notWant := range []string{"<nav>", "Share", "Sign up", "tracking.gif", "headline"} {
+			if strings.Contains(article.Content, notWant) {
+				t.Errorf("content should not contain %q, got:\n%s", notWant, article.Content)
+			}
+		}
+	})
+
+	t.Run("no body match without autodetection", func(t *testing.T) {
+		content := "<p>Text</p><aside>Ad</aside>"
+		article := &models.Article{URL: "https://nobody.example.net/post", Content: content}
+		if err := proc.Process(article, &opts); err != nil {
+			t.Fatalf("Process() returned error: %v", err)
+		}
+		if article.Content != "<p>Text</p>" {
+			t.Errorf("content should only be stripped, got:\n%s", article.Content)
+		}
+	})
+
+	t.Run("no site config without readability fallback", func(t *testing.T) {
+		noFallback := opts
+		noFallback.AdditionalOptions = map[string]any{"directory": dir, "readability": false}
+		content := "<p>Text</p>"
+		article := &models.Article{URL: "https://unknown.example.com/post", Content: content}
+		if err := proc.Process(article, &noFallback); err != nil {
+			t.Fatalf("Process() returned error: %v", err)
+		}
+		if article.Content != content {
+			t.Errorf("content should be unchanged, got:\n%s", article.Content)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		article := &models.Article{URL: "https://example.com/story", Content: exampleSitePage}
+		if err := proc.Process(article, &Options{}); err == nil {
+			t.Error("Process() expected error, got nil")
+		}
+	})
+}
+
+func writeSiteConfig(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// StatsProcessor records an article's word count, reading time, image count
+// and language in its metadata, under the models.Metadata* keys.
+type StatsProcessor struct{}
+
+// NewStatsProcessor creates a new instance of StatsProcessor.
+func NewStatsProcessor() *StatsProcessor {
+	return &StatsProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *StatsProcessor) Name() string {
+	return "stats"
+}
+
+// statsOptions holds the additional options of the stats processor.
+type statsOptions struct {
+	WordsPerMinute int `option:"words_per_minute"`
+}
+
+// check checks that the reading speed is positive.
+func (o *statsOptions) check() error {
+	if o.WordsPerMinute <= 0 {
+		return fmt.Errorf("words_per_minute must be positive, got %d", o.WordsPerMinute)
+	}
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *StatsProcessor) NewOptions() any {
+	return &statsOptions{WordsPerMinute: models.DefaultReadingWPM}
+}
+
+// Process computes statistics from the article's content. The reading speed
+// defaults to models.DefaultReadingWPM and can be set with the
+// "words_per_minute" additional option. A language already recorded in the
+// article's metadata, e.g. by its source, is kept.
+func (p *StatsProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	options, err := processorOptions[statsOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	wpm := options.WordsPerMinute
+	text := models.PlainText(article.Content)
+	words := models.CountWords(text)
+
+	if article.Metadata == nil {
+		article.Metadata = make(map[string]any)
+	}
+	article.Metadata[models.MetadataWordCount] = words
+	article.Metadata[models.MetadataReadingTime] = models.ReadingMinutes(words, wpm)
+	article.Metadata[models.MetadataImageCount] = models.CountImages(article.Content)
+
+	if lang, _ := article.Metadata[models.MetadataLanguage].(string); lang == "" {
+		if lang := detectLanguage(text); lang != "" {
+			article.Metadata[models.MetadataLanguage] = lang
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestStatsProcessor_Process(t *testing.T) {
+	english := strings.Repeat(
+		"<p>The cat sat on the mat and it was happy with the result of this.</p>",
+		100,
+	)
+	german := "<p>Der Hund ist nicht mit der Katze und das ist auch eine gute Sache.</p>"
+
+	tests := []struct {
+		name        string
+		content     string
+		metadata    map[string]any
+		options     map[string]any
+		words       int
+		readingTime int
+		images      int
+		language    string
+	}{
+		{
+			name:        "english article with images",
+			content:     english + `<img src="a.jpg"><img src="b.jpg">`,
+			words:       1500,
+			readingTime: 8,
+			images:      2,
+			language:    "en",
+		},
+		{
+			name:        "custom reading speed",
+			content:     english,
+			options:     map[string]any{"words_per_minute": int64(300)},
+			words:       1500,
+			readingTime: 5,
+			language:    "en",
+		},
+		{
+			name:        "german article",
+			content:     german,
+			words:       14,
+			readingTime: 1,
+			language:    "de",
+		},
+		{
+			name:        "too short to detect language",
+			content:     "<p>Hello world</p>",
+			words:       2,
+			readingTime: 1,
+		},
+		{
+			name:        "keeps language from source",
+			content:     german,
+			metadata:    map[string]any{models.MetadataLanguage: "nl"},
+			words:       14,
+			readingTime: 1,
+			language:    "nl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{Content: tt.content, Metadata: tt.metadata}
+			opts := DefaultOptions()
+			if tt.options != nil {
+				opts.AdditionalOptions = tt.options
+			}
+
+			if err := NewStatsProcessor().Process(article, &opts); err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			if got := article.Metadata[models.MetadataWordCount]; got != tt.words {
+				t.Errorf("word count = %v, want %d", got, tt.words)
+			}
+			if got := article.Metadata[models.MetadataReadingTime]; got != tt.readingTime {
+				t.Errorf("reading time = %v, want %d", got, tt.readingTime)
+			}
+			if got := article.Metadata[models.MetadataImageCount]; got != tt.images {
+				t.Errorf("image count = %v, want %d", got, tt.images)
+			}
+			if got, _ := article.Metadata[models.MetadataLanguage].(string); got != tt.language {
+				t.Errorf("language = %q, want %q", got, tt.language)
+			}
+		})
+	}
+}
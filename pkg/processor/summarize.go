@@ -0,0 +1,351 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// defaultSummarySentences is the number of sentences in a summary when
+// neither a sentence count nor a word budget is configured.
+const defaultSummarySentences = 3
+
+// SummarizeProcessor fills in an article's summary with its most central
+// sentences, ranked with TextRank. It works entirely offline.
+type SummarizeProcessor struct{}
+
+// NewSummarizeProcessor creates a new instance of SummarizeProcessor.
+func NewSummarizeProcessor() *SummarizeProcessor {
+	return &SummarizeProcessor{}
+}
+
+// Name returns the name of this processor.
+func (p *SummarizeProcessor) Name() string {
+	return "summarize"
+}
+
+// summarizeOptions holds the additional options of the summarize processor.
+type summarizeOptions struct {
+	Overwrite bool   `option:"overwrite"`
+	MaxWords  int    `option:"max_words"`
+	Sentences int    `option:"sentences"`
+	Language  string `option:"language"`
+}
+
+// check checks that the limits are not negative, and sets the default
+// number of sentences if neither is set.
+func (o *summarizeOptions) check() error {
+	if o.MaxWords < 0 {
+		return fmt.Errorf("max_words must not be negative, got %d", o.MaxWords)
+	}
+	if o.Sentences < 0 {
+		return fmt.Errorf("sentences must not be negative, got %d", o.Sentences)
+	}
+	if o.Sentences == 0 && o.MaxWords == 0 {
+		o.Sentences = defaultSummarySentences
+	}
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *SummarizeProcessor) NewOptions() any {
+	return &summarizeOptions{}
+}
+
+// Process summarizes the article's content. It is configured with these
+// additional options:
+//
+//   - "sentences": the maximum number of sentences in the summary
+//   - "max_words": the maximum number of words in the summary
+//   - "overwrite": whether to replace an existing summary
+//   - "language": the ISO 639-1 code of the content's language, defaulting to
+//     the article's detected language and then English
+//
+// Without either limit, summaries are three sentences long. Articles that
+// already have a summary are left alone unless "overwrite" is set.
+func (p *SummarizeProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	options, err := processorOptions[summarizeOptions](p, opts)
+	if err != nil {
+		return err
+	}
+	if article.Content == "" || (article.Summary != "" && !options.Overwrite) {
+		return nil
+	}
+
+	language := options.Language
+	if language == "" {
+		language, _ = article.Metadata[models.MetadataLanguage].(string)
+	}
+
+	paragraphs := models.Paragraphs(article.Content)
+	sentences := completeSentences(splitSentences(strings.Join(paragraphs, "\n"), language))
+	summary := summarize(sentences, stopwordsFor(language), options.Sentences, options.MaxWords)
+	if summary != "" {
+		article.Summary = summary
+	}
+
+	return nil
+}
+
+// completeSentences drops text that doesn't end like a sentence, such as
+// headings, captions and list items, unless that would leave nothing.
+func completeSentences(sentences []string) []string {
+	complete := make([]string, 0, len(sentences))
+	for _, sentence := range sentences {
+		trimmed := strings.TrimRight(sentence, sentenceClosers)
+		if strings.ContainsAny(trimmed[max(len(trimmed)-1, 0):], sentenceTerminators) {
+			complete = append(complete, sentence)
+		}
+	}
+
+	if len(complete) == 0 {
+		return sentences
+	}
+	return complete
+}
+
+// summarize picks the highest ranked sentences that fit within maxSentences
+// and maxWords, where zero means no limit, and joins them in their original
+// order. The top ranked sentence is always included.
+func summarize(
+	sentences []string,
+	stopwords map[string]bool,
+	maxSentences, maxWords int,
+) string {
+	if len(sentences) == 0 {
+		return ""
+	}
+
+	scores := textRank(sentences, stopwords)
+	ranked := make([]int, len(sentences))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	slices.SortStableFunc(ranked, func(a, b int) int {
+		switch {
+		case scores[a] > scores[b]:
+			return -1
+		case scores[a] < scores[b]:
+			return 1
+		}
+		return 0
+	})
+
+	chosen := make([]int, 0)
+	words := 0
+	for _, i := range ranked {
+		if maxSentences > 0 && len(chosen) >= maxSentences {
+			break
+		}
+
+		sentenceWords := models.CountWords(sentences[i])
+		if maxWords > 0 && words+sentenceWords > maxWords && len(chosen) > 0 {
+			continue
+		}
+
+		chosen = append(chosen, i)
+		words += sentenceWords
+	}
+
+	slices.Sort(chosen)
+	parts := make([]string, len(chosen))
+	for i, index := range chosen {
+		parts[i] = sentences[index]
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// textRank scores sentences by how central they are to the text, running
+// PageRank over a graph where sentences are linked by the content words they
+// share.
+func textRank(sentences []string, stopwords map[string]bool) []float64 {
+	const (
+		damping    = 0.85
+		iterations = 50
+		tolerance  = 1e-6
+	)
+
+	terms := make([]map[string]bool, len(sentences))
+	for i, sentence := range sentences {
+		terms[i] = make(map[string]bool)
+		for _, word := range tokenize(sentence) {
+			if !stopwords[word] {
+				terms[i][word] = true
+			}
+		}
+	}
+
+	n := len(sentences)
+	weights := make([][]float64, n)
+	totals := make([]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+	}
+	for i := range n {
+		for j := i + 1; j < n; j++ {
+			w := sentenceSimilarity(terms[i], terms[j])
+			weights[i][j], weights[j][i] = w, w
+			totals[i] += w
+			totals[j] += w
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1
+	}
+
+	for range iterations {
+		next := make([]float64, n)
+		delta := 0.0
+		for i := range n {
+			sum := 0.0
+			for j := range n {
+				if weights[j][i] > 0 {
+					sum += weights[j][i] / totals[j] * scores[j]
+				}
+			}
+			next[i] = 1 - damping + damping*sum
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores = next
+		if delta < tolerance {
+			break
+		}
+	}
+
+	return scores
+}
+
+// sentenceSimilarity is the TextRank similarity between two sentences: the
+// number of terms they share, normalized by their lengths so long sentences
+// aren't favoured.
+func sentenceSimilarity(a, b map[string]bool) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+
+	return float64(shared) / (math.Log(float64(len(a))) + math.Log(float64(len(b))))
+}
+
+// splitSentences splits text into sentences. Line breaks always end a
+// sentence, and within a line a sentence ends at ".", "!" or "?" followed by
+// whitespace and an uppercase letter, digit or quote, unless the word before
+// it is a known abbreviation in the given language.
+func splitSentences(text, language string) []string {
+	abbreviations := sentenceAbbreviations[language]
+	if abbreviations == nil {
+		abbreviations = sentenceAbbreviations["en"]
+	}
+
+	sentences := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		runes := []rune(strings.TrimSpace(line))
+		start := 0
+		for i := 0; i < len(runes); i++ {
+			if !isSentenceEnd(runes, i, abbreviations) {
+				continue
+			}
+			sentences = appendSentence(sentences, string(runes[start:i+1]))
+			start = i + 1
+		}
+		sentences = appendSentence(sentences, string(runes[start:]))
+	}
+
+	return sentences
+}
+
+func appendSentence(sentences []string, sentence string) []string {
+	sentence = strings.TrimSpace(sentence)
+	if models.CountWords(sentence) == 0 {
+		return sentences
+	}
+	return append(sentences, sentence)
+}
+
+// Characters that end a sentence, and closing quotes and brackets that may
+// follow them.
+const (
+	sentenceTerminators = ".!?"
+	sentenceClosers     = "\"')]”’»"
+)
+
+// isSentenceEnd reports whether the rune at i ends a sentence.
+func isSentenceEnd(runes []rune, i int, abbreviations map[string]bool) bool {
+	// A sentence ends at terminal punctuation, or at the last of any closing
+	// quotes and brackets that follow it.
+	end := i
+	for end > 0 && strings.ContainsRune(sentenceClosers, runes[end]) {
+		end--
+	}
+	if !strings.ContainsRune(sentenceTerminators, runes[end]) {
+		return false
+	}
+	if i+1 < len(runes) && strings.ContainsRune(sentenceTerminators+sentenceClosers, runes[i+1]) {
+		return false
+	}
+
+	j := i + 1
+	for j < len(runes) && unicode.IsSpace(runes[j]) {
+		j++
+	}
+	if j == len(runes) {
+		return true
+	}
+	// Without whitespace, this is something like "3.14" or "example.com".
+	if j == i+1 {
+		return false
+	}
+
+	next := runes[j]
+	opensSentence := unicode.IsUpper(next) || unicode.IsDigit(next) ||
+		strings.ContainsRune("\"'“‘«¿¡", next)
+	if !opensSentence {
+		return false
+	}
+
+	if runes[end] == '.' {
+		k := end
+		for k > 0 && !unicode.IsSpace(runes[k-1]) {
+			k--
+		}
+		word := strings.ToLower(strings.Trim(string(runes[k:end]), "\"'(“‘«"))
+		// Single letters are initials, as in "J. R. R. Tolkien".
+		if abbreviations[word] || len([]rune(word)) == 1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sentenceAbbreviations are abbreviations, without their final period, that
+// don't end a sentence.
+var sentenceAbbreviations = map[string]map[string]bool{
+	"en": wordSet(
+		"mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "vs", "etc", "e.g", "i.e",
+		"inc", "ltd", "co", "corp", "no", "fig", "approx", "dept", "est", "gen",
+		"gov", "sen", "rep", "u.s", "u.k", "jan", "feb", "mar", "apr", "jun", "jul",
+		"aug", "sep", "sept", "oct", "nov", "dec", "a.m", "p.m",
+	),
+	"de": wordSet("z.b", "bzw", "usw", "vgl", "ca", "dr", "prof", "nr", "d.h", "u.a", "evtl"),
+	"fr": wordSet("m", "mme", "mlle", "dr", "etc", "p.ex", "cf", "env", "av", "apr"),
+	"es": wordSet("sr", "sra", "srta", "dr", "dra", "etc", "p.ej", "ud", "uds", "av"),
+}
@@ -0,0 +1,139 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		want     []string
+	}{
+		{
+			name: "basic punctuation",
+			text: "First sentence. Second one! A third? Yes.",
+			want: []string{"First sentence.", "Second one!", "A third?", "Yes."},
+		},
+		{
+			name: "abbreviations and initials",
+			text: "Dr. Smith met J. R. Tolkien at 3 p.m. on Monday. They talked.",
+			want: []string{"Dr. Smith met J. R. Tolkien at 3 p.m. on Monday.", "They talked."},
+		},
+		{
+			name: "numbers, domains and lowercase continuations",
+			text: "Pi is 3.14 according to example.com and e.g. other sites. Done.",
+			want: []string{"Pi is 3.14 according to example.com and e.g. other sites.", "Done."},
+		},
+		{
+			name: "quotes and line breaks",
+			text: "He said \"stop.\" Then left.\nA heading\nNext paragraph.",
+			want: []string{"He said \"stop.\"", "Then left.", "A heading", "Next paragraph."},
+		},
+		{
+			name:     "language specific abbreviations",
+			text:     "Das gilt z.B. für Äpfel. Und Birnen.",
+			language: "de",
+			want:     []string{"Das gilt z.B. für Äpfel.", "Und Birnen."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSentences(tt.text, tt.language)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeProcessor_Process(t *testing.T) {
+	content := `<h1>Solar power</h1>
+		<p>Solar power capacity grew rapidly this year as panel prices fell.
+		Cheaper solar panels made solar power the fastest growing energy source.
+		The weather was pleasant for most of the summer.</p>
+		<p>Analysts expect solar capacity to keep growing as panel prices keep falling.
+		My neighbour adopted a cat.
+		Grid operators are adding storage to handle growing solar power output.</p>`
+
+	tests := []struct {
+		name    string
+		summary string
+		options map[string]any
+		check   func(t *testing.T, summary string)
+	}{
+		{
+			name:    "default sentence count",
+			options: map[string]any{},
+			check: func(t *testing.T, summary string) {
+				if n := len(splitSentences(summary, "en")); n != 3 {
+					t.Errorf("summary has %d sentences, want 3: %q", n, summary)
+				}
+				if strings.Contains(summary, "cat") || strings.Contains(summary, "weather") {
+					t.Errorf("summary should skip off-topic sentences: %q", summary)
+				}
+			},
+		},
+		{
+			name:    "sentences in original order",
+			options: map[string]any{"sentences": int64(2)},
+			check: func(t *testing.T, summary string) {
+				sentences := splitSentences(summary, "en")
+				if len(sentences) != 2 {
+					t.Fatalf("summary has %d sentences, want 2: %q", len(sentences), summary)
+				}
+				if strings.Index(content, sentences[0]) > strings.Index(content, sentences[1]) {
+					t.Errorf("summary sentences are out of order: %q", summary)
+				}
+			},
+		},
+		{
+			name:    "word budget",
+			options: map[string]any{"max_words": int64(25)},
+			check: func(t *testing.T, summary string) {
+				if words := models.CountWords(summary); words == 0 || words > 25 {
+					t.Errorf("summary has %d words, want 1 to 25: %q", words, summary)
+				}
+			},
+		},
+		{
+			name:    "keeps existing summary",
+			summary: "From the feed",
+			options: map[string]any{},
+			check: func(t *testing.T, summary string) {
+				if summary != "From the feed" {
+					t.Errorf("summary = %q, want the existing summary", summary)
+				}
+			},
+		},
+		{
+			name:    "overwrites existing summary",
+			summary: "From the feed",
+			options: map[string]any{"overwrite": true},
+			check: func(t *testing.T, summary string) {
+				if summary == "From the feed" {
+					t.Error("summary should have been overwritten")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{Content: content, Summary: tt.summary}
+			opts := DefaultOptions()
+			opts.AdditionalOptions = tt.options
+
+			if err := NewSummarizeProcessor().Process(article, &opts); err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			tt.check(t, article.Summary)
+		})
+	}
+}
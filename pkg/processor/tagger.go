@@ -0,0 +1,337 @@
+package processor
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// Keyword extraction methods supported by the tagger.
+const (
+	// KeywordsTFIDF picks words that are frequent in an article but rare
+	// across the rest of the digest
+	KeywordsTFIDF = "tfidf"
+
+	// KeywordsRAKE picks key phrases using Rapid Automatic Keyword Extraction
+	KeywordsRAKE = "rake"
+
+	// KeywordsNone disables keyword extraction, so only rules apply
+	KeywordsNone = "none"
+)
+
+// defaultMaxKeywordTags is the number of keyword tags added to each article
+// unless configured otherwise.
+const defaultMaxKeywordTags = 5
+
+// minTermFrequency is the number of times a word must appear in an article
+// to be considered one of its TF-IDF keywords.
+const minTermFrequency = 2
+
+// TaggerProcessor adds tags to articles, from user-defined rules and from
+// keywords extracted from their text. Tags are merged with the article's
+// existing tags.
+type TaggerProcessor struct {
+	// pending holds the term frequencies of articles waiting for TF-IDF
+	// keywords, which can only be picked once the whole digest is known.
+	pending map[*models.Article]map[string]int
+}
+
+// NewTaggerProcessor creates a new instance of TaggerProcessor.
+func NewTaggerProcessor() *TaggerProcessor {
+	return &TaggerProcessor{pending: make(map[*models.Article]map[string]int)}
+}
+
+// Name returns the name of this processor.
+func (p *TaggerProcessor) Name() string {
+	return "tagger"
+}
+
+// taggerOptions holds the additional options of the tagger processor.
+type taggerOptions struct {
+	Keywords string              `option:"keywords"`
+	MaxTags  int                 `option:"max_tags"`
+	Rules    map[string][]string `option:"rules"`
+
+	rules []tagRule
+}
+
+// check checks the keyword extraction method and compiles the tag rules,
+// ordered by tag.
+func (o *taggerOptions) check() error {
+	switch o.Keywords {
+	case KeywordsTFIDF, KeywordsRAKE, KeywordsNone:
+	default:
+		return fmt.Errorf(
+			"invalid keywords method %q: must be one of %q, %q or %q",
+			o.Keywords,
+			KeywordsTFIDF,
+			KeywordsRAKE,
+			KeywordsNone,
+		)
+	}
+
+	if o.MaxTags < 0 {
+		return fmt.Errorf("max_tags must not be negative, got %d", o.MaxTags)
+	}
+
+	o.rules = nil
+	for _, tag := range slices.Sorted(maps.Keys(o.Rules)) {
+		words := o.Rules[tag]
+		if len(words) == 0 {
+			continue
+		}
+
+		quoted := make([]string, len(words))
+		for i, word := range words {
+			quoted[i] = regexp.QuoteMeta(word)
+		}
+		re := regexp.MustCompile(
+			`(?i)(?:^|[^\p{L}\p{N}_])(?:` + strings.Join(quoted, "|") + `)(?:$|[^\p{L}\p{N}_])`,
+		)
+		o.rules = append(o.rules, tagRule{tag: tag, re: re})
+	}
+
+	return nil
+}
+
+// NewOptions returns the default additional options of this processor.
+func (p *TaggerProcessor) NewOptions() any {
+	return &taggerOptions{Keywords: KeywordsTFIDF, MaxTags: defaultMaxKeywordTags}
+}
+
+// Process tags an article. It is configured with these additional options:
+//
+//   - "rules": a table mapping a tag to the words that apply it, matched as
+//     whole words in the title, summary and content, ignoring case
+//   - "keywords": how to extract keyword tags, one of "tfidf" (the default),
+//     "rake" or "none"
+//   - "max_tags": the maximum number of keyword tags added to each article
+//
+// Rule tags and RAKE keywords are added immediately. TF-IDF keywords are
+// added by ProcessDigest, as they depend on the other articles in the digest.
+func (p *TaggerProcessor) Process(article *models.Article, opts *Options) error {
+	if article == nil {
+		return errors.New("article cannot be nil")
+	}
+
+	options, err := processorOptions[taggerOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	text := models.PlainText(article.Content)
+	searchText := article.Title + "\n" + models.PlainText(article.Summary) + "\n" + text
+
+	for _, rule := range options.rules {
+		if rule.matches(searchText) {
+			article.Tags = mergeTags(article.Tags, rule.tag)
+		}
+	}
+
+	language, _ := article.Metadata[models.MetadataLanguage].(string)
+	stopwords := stopwordsFor(language)
+
+	switch options.Keywords {
+	case KeywordsRAKE:
+		paragraphs := models.Paragraphs(article.Content)
+		keywords := rakeKeywords(paragraphs, stopwords, options.MaxTags)
+		article.Tags = mergeTags(article.Tags, keywords...)
+	case KeywordsTFIDF:
+		p.pending[article] = termFrequencies(article.Title+"\n"+text, stopwords)
+	}
+
+	return nil
+}
+
+// ProcessDigest adds TF-IDF keyword tags to the articles this processor has
+// processed, weighing words against every article in the digest.
+func (p *TaggerProcessor) ProcessDigest(articles []*models.Article, opts *Options) error {
+	if len(p.pending) == 0 {
+		return nil
+	}
+
+	options, err := processorOptions[taggerOptions](p, opts)
+	if err != nil {
+		return err
+	}
+
+	frequencies := make([]map[string]int, len(articles))
+	documentFrequency := make(map[string]int)
+	for i, article := range articles {
+		terms, ok := p.pending[article]
+		if !ok {
+			language, _ := article.Metadata[models.MetadataLanguage].(string)
+			text := article.Title + "\n" + models.PlainText(article.Content)
+			terms = termFrequencies(text, stopwordsFor(language))
+		}
+		frequencies[i] = terms
+
+		for term := range terms {
+			documentFrequency[term]++
+		}
+	}
+
+	n := float64(len(articles))
+	for i, article := range articles {
+		if _, ok := p.pending[article]; !ok {
+			continue
+		}
+
+		type scoredTerm struct {
+			term  string
+			score float64
+		}
+		scored := make([]scoredTerm, 0)
+		for term, count := range frequencies[i] {
+			if count < minTermFrequency {
+				continue
+			}
+			idf := math.Log((1+n)/(1+float64(documentFrequency[term]))) + 1
+			scored = append(scored, scoredTerm{term: term, score: float64(count) * idf})
+		}
+		slices.SortFunc(scored, func(a, b scoredTerm) int {
+			if c := cmp.Compare(b.score, a.score); c != 0 {
+				return c
+			}
+			return strings.Compare(a.term, b.term)
+		})
+
+		for _, st := range scored[:min(options.MaxTags, len(scored))] {
+			article.Tags = mergeTags(article.Tags, st.term)
+		}
+	}
+
+	clear(p.pending)
+
+	return nil
+}
+
+// tagRule applies a tag to articles mentioning any of its words.
+type tagRule struct {
+	tag string
+	re  *regexp.Regexp
+}
+
+func (r tagRule) matches(text string) bool {
+	return r.re.MatchString(text)
+}
+
+// mergeTags appends tags that aren't already present, ignoring case.
+func mergeTags(existing []string, tags ...string) []string {
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		if slices.ContainsFunc(existing, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		existing = append(existing, tag)
+	}
+
+	return existing
+}
+
+// termFrequencies counts the words in text that could be keywords: words of
+// at least three letters that aren't stopwords or numbers.
+func termFrequencies(text string, stopwords map[string]bool) map[string]int {
+	terms := make(map[string]int)
+	for _, word := range tokenize(text) {
+		word = strings.Trim(word, "'")
+		if !isKeyword(word, stopwords) {
+			continue
+		}
+		terms[word]++
+	}
+
+	return terms
+}
+
+func isKeyword(word string, stopwords map[string]bool) bool {
+	if len([]rune(word)) < 3 || stopwords[word] || strings.Contains(word, "'") {
+		return false
+	}
+
+	return strings.IndexFunc(word, unicode.IsLetter) >= 0
+}
+
+// rakeKeywords extracts up to limit key phrases with RAKE. Candidate phrases
+// are runs of words between stopwords and punctuation. Each word is scored
+// by its degree (how many words it co-occurs with in phrases) over its
+// frequency, and each phrase by the sum of its words' scores.
+func rakeKeywords(paragraphs []string, stopwords map[string]bool, limit int) []string {
+	const maxPhraseWords = 3
+
+	phrases := make([][]string, 0)
+	for _, paragraph := range paragraphs {
+		for _, fragment := range strings.FieldsFunc(paragraph, isPhraseDelimiter) {
+			var phrase []string
+			for _, word := range tokenize(fragment) {
+				word = strings.Trim(word, "'")
+				if isKeyword(word, stopwords) {
+					phrase = append(phrase, word)
+					continue
+				}
+				if len(phrase) > 0 {
+					phrases = append(phrases, phrase)
+				}
+				phrase = nil
+			}
+			if len(phrase) > 0 {
+				phrases = append(phrases, phrase)
+			}
+		}
+	}
+
+	frequency := make(map[string]int)
+	degree := make(map[string]int)
+	for _, phrase := range phrases {
+		for _, word := range phrase {
+			frequency[word]++
+			degree[word] += len(phrase)
+		}
+	}
+
+	scores := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, phrase := range phrases {
+		if len(phrase) > maxPhraseWords {
+			continue
+		}
+		key := strings.Join(phrase, " ")
+		counts[key]++
+		if _, ok := scores[key]; ok {
+			continue
+		}
+		for _, word := range phrase {
+			scores[key] += float64(degree[word]) / float64(frequency[word])
+		}
+	}
+
+	keys := make([]string, 0, len(scores))
+	for key := range scores {
+		// Key phrases must recur, or every long phrase would outrank them.
+		if counts[key] >= minTermFrequency {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := cmp.Compare(scores[b], scores[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	return keys[:min(limit, len(keys))]
+}
+
+func isPhraseDelimiter(r rune) bool {
+	return unicode.IsPunct(r) && r != '\'' && r != '-' && r != '’'
+}
@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestTaggerProcessor_Rules(t *testing.T) {
+	rules := map[string]any{
+		"go":   []any{"golang", "goroutine"},
+		"rust": []any{"rustc", "cargo"},
+		"ai":   []any{"LLM"},
+	}
+
+	tests := []struct {
+		name    string
+		article *models.Article
+		want    []string
+	}{
+		{
+			name: "matches content and keeps existing tags",
+			article: &models.Article{
+				Title:   "Concurrency patterns",
+				Content: "<p>Every goroutine needs a way to stop.</p>",
+				Tags:    []string{"programming"},
+			},
+			want: []string{"programming", "go"},
+		},
+		{
+			name: "matches title ignoring case",
+			article: &models.Article{
+				Title:   "Building an llm from scratch with Cargo",
+				Content: "<p>Nothing else.</p>",
+			},
+			want: []string{"ai", "rust"},
+		},
+		{
+			name: "whole words only",
+			article: &models.Article{
+				Title:   "Golangers and cargoes",
+				Content: "<p>Nothing to see.</p>",
+			},
+			want: nil,
+		},
+		{
+			name: "does not duplicate existing tags",
+			article: &models.Article{
+				Content: "<p>Golang 1.24 is out.</p>",
+				Tags:    []string{"Go"},
+			},
+			want: []string{"Go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.AdditionalOptions = map[string]any{"rules": rules, "keywords": KeywordsNone}
+
+			if err := NewTaggerProcessor().Process(tt.article, &opts); err != nil {
+				t.Fatalf("Process() returned error: %v", err)
+			}
+
+			if !slices.Equal(tt.article.Tags, tt.want) {
+				t.Errorf("Tags = %v, want %v", tt.article.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaggerProcessor_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]any
+	}{
+		{name: "rules not a table", options: map[string]any{"rules": "go"}},
+		{name: "rule not a list", options: map[string]any{"rules": map[string]any{"go": 1}}},
+		{name: "unknown keywords method", options: map[string]any{"keywords": "magic"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.AdditionalOptions = tt.options
+
+			article := &models.Article{Content: "<p>Text</p>"}
+			if err := NewTaggerProcessor().Process(article, &opts); err == nil {
+				t.Error("Process() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTaggerProcessor_TFIDF(t *testing.T) {
+	articles := []*models.Article{
+		{
+			Title: "Kubernetes operators",
+			Content: "<p>Kubernetes operators automate clusters. Operators watch " +
+				"resources and operators reconcile state in the cluster.</p>",
+		},
+		{
+			Title: "Sourdough baking",
+			Content: "<p>Sourdough needs a starter. Feed the starter daily and " +
+				"the sourdough will rise in the cluster of loaves.</p>",
+		},
+	}
+
+	p := NewTaggerProcessor()
+	opts := DefaultOptions()
+	opts.AdditionalOptions = map[string]any{"max_tags": int64(2)}
+
+	for _, article := range articles {
+		if err := p.Process(article, &opts); err != nil {
+			t.Fatalf("Process() returned error: %v", err)
+		}
+		if len(article.Tags) != 0 {
+			t.Errorf("TF-IDF tags should wait for the digest, got %v", article.Tags)
+		}
+	}
+
+	if err := p.ProcessDigest(articles, &opts); err != nil {
+		t.Fatalf("ProcessDigest() returned error: %v", err)
+	}
+
+	want := [][]string{{"operators", "kubernetes"}, {"sourdough", "starter"}}
+	for i, article := range articles {
+		if !slices.Equal(article.Tags, want[i]) {
+			t.Errorf("article %d tags = %v, want %v", i, article.Tags, want[i])
+		}
+	}
+}
+
+func TestRakeKeywords(t *testing.T) {
+	paragraphs := []string{
+		"Compatibility of systems of linear constraints over the set of natural numbers.",
+		"Criteria of compatibility of a system of linear Diophantine equations are given.",
+		"Linear constraints and linear Diophantine equations are studied.",
+	}
+
+	got := rakeKeywords(paragraphs, stopwordsFor("en"), 3)
+	want := []string{"linear diophantine equations", "linear constraints", "compatibility"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("rakeKeywords() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize splits text into lowercase words.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// stopwordsFor returns the stopwords of a language, falling back to English.
+func stopwordsFor(language string) map[string]bool {
+	words, ok := stopwordLists[language]
+	if !ok {
+		words = stopwordLists["en"]
+	}
+
+	stopwords := make(map[string]bool, len(words))
+	for _, word := range words {
+		stopwords[word] = true
+	}
+	return stopwords
+}
+
+// stopwordLists are common words that carry little meaning on their own.
+// Lists for languages other than English are short, covering only the most
+// common words.
+var stopwordLists = map[string][]string{
+	"en": {
+		"a", "about", "above", "after", "again", "against", "all", "also", "am", "an",
+		"and", "any", "are", "as", "at", "be", "because", "been", "before", "being",
+		"below", "between", "both", "but", "by", "can", "could", "did", "do", "does",
+		"doing", "down", "during", "each", "even", "few", "for", "from", "further",
+		"get", "got", "had", "has", "have", "having", "he", "her", "here", "hers",
+		"herself", "him", "himself", "his", "how", "however", "i", "if", "in", "into",
+		"is", "it", "it's", "its", "itself", "just", "like", "made", "make", "many",
+		"may", "me", "might", "more", "most", "much", "must", "my", "myself", "new",
+		"no", "nor", "not", "now", "of", "off", "on", "once", "one", "only", "or",
+		"other", "our", "ours", "ourselves", "out", "over", "own", "said", "same",
+		"say", "says", "she", "should", "so", "some", "such", "than", "that",
+		"that's", "the", "their", "theirs", "them", "themselves", "then", "there",
+		"these", "they", "this", "those", "through", "to", "too", "two", "under",
+		"until", "up", "us", "very", "was", "we", "were", "what", "when", "where",
+		"which", "while", "who", "whom", "why", "will", "with", "would", "you",
+		"your", "yours", "yourself", "yourselves",
+	},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "eine", "auf", "sich", "auch"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "pour", "dans", "que", "pas", "sur"},
+	"es": {"el", "la", "los", "las", "y", "que", "es", "por", "una", "para", "con", "del"},
+	"it": {"il", "di", "che", "e", "la", "per", "una", "sono", "non", "della", "con", "gli"},
+	"pt": {"o", "os", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "met", "voor", "zijn"},
+}
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -78,13 +78,13 @@ func (s *Source) Fetch(ctx context.Context, fetcher fetcher.Fetcher) ([]*models.
 			continue
 		}
 
+		// Items without a date are left with a zero PublishedAt so the
+		// pipeline can apply the source's undated policy.
 		var publishedAt time.Time
 		if item.PublishedParsed != nil {
 			publishedAt = *item.PublishedParsed
 		} else if item.UpdatedParsed != nil {
 			publishedAt = *item.UpdatedParsed
-		} else {
-			publishedAt = time.Now()
 		}
 
 		content := item.Content
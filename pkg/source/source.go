@@ -3,9 +3,10 @@ package source
 import (
 	"context"
 	"fmt"
-	"strings"
+	"log"
 
 	"github.com/shrik450/dijester/pkg/fetcher"
+	"github.com/shrik450/dijester/pkg/filter"
 	"github.com/shrik450/dijester/pkg/models"
 	"github.com/shrik450/dijester/pkg/processor"
 	"github.com/shrik450/dijester/pkg/source/hackernews"
@@ -26,6 +27,37 @@ type SourceConfig struct {
 	// WordDenylist contains words that will cause articles to be filtered out
 	WordDenylist []string `toml:"word_denylist"`
 
+	// WordAllowlist contains words of which articles must match at least one
+	// to be included
+	WordAllowlist []string `toml:"word_allowlist"`
+
+	// WordMatch controls how word lists match: "substring", "word" or "regex"
+	WordMatch string `toml:"word_match"`
+
+	// WordFields lists the article fields word lists are matched against:
+	// "title", "summary" and "content". Defaults to all of them.
+	WordFields []string `toml:"word_fields"`
+
+	// Filter is an expression articles must match to be included, e.g.
+	// `score >= 200 && !(title =~ "(?i)crypto")`
+	Filter string `toml:"filter"`
+
+	// AllowedLanguages drops articles detected to be in other languages,
+	// e.g. ["en", "de"]
+	AllowedLanguages []string `toml:"allowed_languages"`
+
+	// MaxAge drops articles published longer ago than this duration, e.g.
+	// "36h"
+	MaxAge string `toml:"max_age"`
+
+	// Since drops articles published before a reference point. The only
+	// supported value is "last_run", the last successful run of this source.
+	Since string `toml:"since"`
+
+	// UndatedPolicy controls what happens to articles without a publication
+	// date when a time window is configured: "keep", "drop" or "first_seen"
+	UndatedPolicy string `toml:"undated_policy"`
+
 	// FetcherConfig contains configuration for the fetcher
 	FetcherConfig *fetcher.FetcherConfig `toml:"fetcher_config"`
 
@@ -36,6 +68,36 @@ type SourceConfig struct {
 	Options map[string]any `toml:"options"`
 }
 
+// Validate checks the source configuration for invalid values.
+func (c SourceConfig) Validate() error {
+	if c.MaxArticles < 0 {
+		return fmt.Errorf("max_articles must not be negative, got %d", c.MaxArticles)
+	}
+
+	if err := c.validateTimeWindow(); err != nil {
+		return err
+	}
+
+	if _, err := filter.Compile(c.Filter); err != nil {
+		return err
+	}
+
+	if _, err := c.WordFilter(); err != nil {
+		return err
+	}
+
+	if err := models.ValidateLanguages(c.AllowedLanguages); err != nil {
+		return fmt.Errorf("allowed_languages: %w", err)
+	}
+
+	return nil
+}
+
+// WordFilter returns the word denylist and allowlist filter for this source.
+func (c SourceConfig) WordFilter() (*WordFilter, error) {
+	return NewWordFilter(c.WordDenylist, c.WordAllowlist, c.WordMatch, c.WordFields)
+}
+
 // Source defines the interface that all content sources must implement.
 type Source interface {
 	// Name returns a unique identifier for this source
@@ -73,28 +135,15 @@ func New(name string) (Source, error) {
 }
 
 // FilterArticlesByWordDenylist filters out articles that contain any of the denylisted words
-// in their title, content, or summary. The comparison is case-insensitive.
-// Returns a new slice containing only the articles that don't match any denylisted words.
+// in their title, content, or summary. The comparison is case-insensitive, and content is
+// matched as plain text. Returns a new slice containing only the articles that don't match
+// any denylisted words.
 func FilterArticlesByWordDenylist(articles []*models.Article, denylist []string) []*models.Article {
-	if len(articles) == 0 || len(denylist) == 0 {
+	wordFilter, err := NewWordFilter(denylist, nil, WordMatchSubstring, nil)
+	if err != nil {
+		log.Printf("Error compiling word denylist: %v", err)
 		return articles
 	}
 
-	filtered := make([]*models.Article, 0, len(articles))
-	for _, article := range articles {
-		isFiltered := false
-		for _, denyWord := range denylist {
-			if strings.Contains(strings.ToLower(article.Title), strings.ToLower(denyWord)) ||
-				strings.Contains(strings.ToLower(article.Content), strings.ToLower(denyWord)) ||
-				strings.Contains(strings.ToLower(article.Summary), strings.ToLower(denyWord)) {
-				isFiltered = true
-				break
-			}
-		}
-		if !isFiltered {
-			filtered = append(filtered, article)
-		}
-	}
-
-	return filtered
+	return wordFilter.FilterArticles(articles)
 }
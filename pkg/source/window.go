@@ -0,0 +1,131 @@
+package source
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// SinceLastRun restricts a source to articles published after its last
+// successful run.
+const SinceLastRun = "last_run"
+
+// Policies for articles without a publication date.
+const (
+	// UndatedKeep keeps undated articles regardless of the time window
+	UndatedKeep = "keep"
+
+	// UndatedDrop drops undated articles whenever a time window is set
+	UndatedDrop = "drop"
+
+	// UndatedFirstSeen uses the time the article was first fetched as its
+	// publication date
+	UndatedFirstSeen = "first_seen"
+)
+
+// HasTimeWindow reports whether this source restricts articles by age.
+func (c SourceConfig) HasTimeWindow() bool {
+	return c.MaxAge != "" || c.Since != ""
+}
+
+// NeedsState reports whether this source needs state persisted across runs.
+func (c SourceConfig) NeedsState() bool {
+	return c.Since == SinceLastRun ||
+		(c.HasTimeWindow() && c.UndatedPolicy == UndatedFirstSeen)
+}
+
+// Cutoff returns the earliest publication time allowed for this source, given
+// the current time and the time of its last successful run. A zero time means
+// there is no cutoff.
+func (c SourceConfig) Cutoff(now, lastRun time.Time) (time.Time, error) {
+	var cutoff time.Time
+
+	if c.MaxAge != "" {
+		maxAge, err := time.ParseDuration(c.MaxAge)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid max_age %q: %w", c.MaxAge, err)
+		}
+		cutoff = now.Add(-maxAge)
+	}
+
+	if c.Since == SinceLastRun && lastRun.After(cutoff) {
+		cutoff = lastRun
+	}
+
+	return cutoff, nil
+}
+
+func (c SourceConfig) validateTimeWindow() error {
+	if c.MaxAge != "" {
+		maxAge, err := time.ParseDuration(c.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max_age %q: %w", c.MaxAge, err)
+		}
+		if maxAge <= 0 {
+			return fmt.Errorf("max_age must be positive, got %q", c.MaxAge)
+		}
+	}
+
+	if c.Since != "" && c.Since != SinceLastRun {
+		return fmt.Errorf("invalid since %q: only %q is supported", c.Since, SinceLastRun)
+	}
+
+	switch c.UndatedPolicy {
+	case "", UndatedKeep, UndatedDrop, UndatedFirstSeen:
+	default:
+		return fmt.Errorf(
+			"invalid undated_policy %q: must be one of %q, %q or %q",
+			c.UndatedPolicy,
+			UndatedKeep,
+			UndatedDrop,
+			UndatedFirstSeen,
+		)
+	}
+
+	return nil
+}
+
+// FilterArticlesByTime drops articles published before the cutoff. Articles
+// with a zero PublishedAt are handled according to the undated policy; with
+// UndatedFirstSeen, firstSeen is called to obtain the time the article was
+// first fetched, which then becomes its publication date. A zero cutoff keeps
+// every article, though first seen times are still recorded so that later
+// runs can window them.
+func FilterArticlesByTime(
+	articles []*models.Article,
+	cutoff time.Time,
+	undatedPolicy string,
+	firstSeen func(article *models.Article) time.Time,
+) []*models.Article {
+	if len(articles) == 0 {
+		return articles
+	}
+
+	filtered := make([]*models.Article, 0, len(articles))
+	for _, article := range articles {
+		if article.PublishedAt.IsZero() {
+			switch undatedPolicy {
+			case UndatedDrop:
+				if !cutoff.IsZero() {
+					continue
+				}
+			case UndatedFirstSeen:
+				if firstSeen != nil {
+					article.PublishedAt = firstSeen(article)
+				}
+			default:
+				filtered = append(filtered, article)
+				continue
+			}
+		}
+
+		if !cutoff.IsZero() && article.PublishedAt.Before(cutoff) {
+			continue
+		}
+
+		filtered = append(filtered, article)
+	}
+
+	return filtered
+}
@@ -0,0 +1,181 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestSourceConfig_Cutoff(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		config  SourceConfig
+		lastRun time.Time
+		want    time.Time
+	}{
+		{
+			name:   "no window",
+			config: SourceConfig{},
+			want:   time.Time{},
+		},
+		{
+			name:   "max age only",
+			config: SourceConfig{MaxAge: "36h"},
+			want:   now.Add(-36 * time.Hour),
+		},
+		{
+			name:    "since last run",
+			config:  SourceConfig{Since: SinceLastRun},
+			lastRun: now.Add(-24 * time.Hour),
+			want:    now.Add(-24 * time.Hour),
+		},
+		{
+			name:   "since last run without previous run",
+			config: SourceConfig{Since: SinceLastRun},
+			want:   time.Time{},
+		},
+		{
+			name:    "later of max age and last run",
+			config:  SourceConfig{MaxAge: "12h", Since: SinceLastRun},
+			lastRun: now.Add(-24 * time.Hour),
+			want:    now.Add(-12 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.Cutoff(now, tt.lastRun)
+			if err != nil {
+				t.Fatalf("Cutoff() returned error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Cutoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceConfig_ValidateTimeWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    SourceConfig
+		mustError bool
+	}{
+		{name: "empty", config: SourceConfig{}},
+		{name: "valid", config: SourceConfig{MaxAge: "36h", Since: SinceLastRun}},
+		{name: "invalid max age", config: SourceConfig{MaxAge: "soon"}, mustError: true},
+		{name: "negative max age", config: SourceConfig{MaxAge: "-1h"}, mustError: true},
+		{name: "invalid since", config: SourceConfig{Since: "yesterday"}, mustError: true},
+		{
+			name:      "invalid undated policy",
+			config:    SourceConfig{UndatedPolicy: "guess"},
+			mustError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.mustError && err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+			if !tt.mustError && err != nil {
+				t.Errorf("Validate() returned unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFilterArticlesByTime(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-24 * time.Hour)
+
+	newArticles := func() []*models.Article {
+		return []*models.Article{
+			{Title: "Recent", PublishedAt: now.Add(-1 * time.Hour)},
+			{Title: "Old", PublishedAt: now.Add(-48 * time.Hour)},
+			{Title: "Undated"},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		cutoff    time.Time
+		policy    string
+		firstSeen time.Time
+		want      []string
+	}{
+		{
+			name:   "keep undated",
+			cutoff: cutoff,
+			policy: UndatedKeep,
+			want:   []string{"Recent", "Undated"},
+		},
+		{
+			name:   "default policy keeps undated",
+			cutoff: cutoff,
+			want:   []string{"Recent", "Undated"},
+		},
+		{
+			name:   "drop undated",
+			cutoff: cutoff,
+			policy: UndatedDrop,
+			want:   []string{"Recent"},
+		},
+		{
+			name:      "first seen within window",
+			cutoff:    cutoff,
+			policy:    UndatedFirstSeen,
+			firstSeen: now,
+			want:      []string{"Recent", "Undated"},
+		},
+		{
+			name:      "first seen outside window",
+			cutoff:    cutoff,
+			policy:    UndatedFirstSeen,
+			firstSeen: now.Add(-72 * time.Hour),
+			want:      []string{"Recent"},
+		},
+		{
+			name:   "zero cutoff keeps everything",
+			policy: UndatedDrop,
+			want:   []string{"Recent", "Old", "Undated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstSeen := func(*models.Article) time.Time { return tt.firstSeen }
+			got := FilterArticlesByTime(newArticles(), tt.cutoff, tt.policy, firstSeen)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf(
+					"FilterArticlesByTime() returned %d articles, want %d",
+					len(got),
+					len(tt.want),
+				)
+			}
+			for i, article := range got {
+				if article.Title != tt.want[i] {
+					t.Errorf("article %d = %q, want %q", i, article.Title, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilterArticlesByTime_FirstSeenSetsPublishedAt(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	articles := []*models.Article{{Title: "Undated"}}
+
+	FilterArticlesByTime(articles, time.Time{}, UndatedFirstSeen, func(*models.Article) time.Time {
+		return now
+	})
+
+	if !articles[0].PublishedAt.Equal(now) {
+		t.Errorf("PublishedAt = %v, want first seen time %v", articles[0].PublishedAt, now)
+	}
+}
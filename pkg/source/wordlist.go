@@ -0,0 +1,177 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+// Ways words in a denylist or allowlist can match article text.
+const (
+	// WordMatchSubstring matches words anywhere in the text, ignoring case
+	WordMatchSubstring = "substring"
+
+	// WordMatchWord matches whole words only, ignoring case
+	WordMatchWord = "word"
+
+	// WordMatchRegex treats each entry as a regular expression
+	WordMatchRegex = "regex"
+)
+
+// Article fields word lists can be matched against.
+const (
+	WordFieldTitle   = "title"
+	WordFieldSummary = "summary"
+	WordFieldContent = "content"
+)
+
+var defaultWordFields = []string{WordFieldTitle, WordFieldSummary, WordFieldContent}
+
+// WordFilter filters articles using a denylist and an allowlist of words.
+type WordFilter struct {
+	deny   []wordRule
+	allow  []wordRule
+	fields []string
+}
+
+type wordRule struct {
+	word string
+	re   *regexp.Regexp
+}
+
+// NewWordFilter compiles word lists into a WordFilter. Articles matching any
+// denylist entry are dropped; if the allowlist is non-empty, articles must
+// also match at least one of its entries. An empty mode defaults to
+// WordMatchSubstring, and empty fields default to title, summary and content.
+func NewWordFilter(
+	denylist, allowlist []string,
+	mode string,
+	fields []string,
+) (*WordFilter, error) {
+	if len(fields) == 0 {
+		fields = defaultWordFields
+	}
+	for _, field := range fields {
+		if !slices.Contains(defaultWordFields, field) {
+			return nil, fmt.Errorf(
+				"invalid word field %q: must be one of %s",
+				field,
+				strings.Join(defaultWordFields, ", "),
+			)
+		}
+	}
+
+	deny, err := compileWordRules(denylist, mode)
+	if err != nil {
+		return nil, fmt.Errorf("word_denylist: %w", err)
+	}
+
+	allow, err := compileWordRules(allowlist, mode)
+	if err != nil {
+		return nil, fmt.Errorf("word_allowlist: %w", err)
+	}
+
+	return &WordFilter{deny: deny, allow: allow, fields: fields}, nil
+}
+
+func compileWordRules(words []string, mode string) ([]wordRule, error) {
+	rules := make([]wordRule, 0, len(words))
+	for _, word := range words {
+		var pattern string
+		switch mode {
+		case "", WordMatchSubstring:
+			pattern = "(?i)" + regexp.QuoteMeta(word)
+		case WordMatchWord:
+			// \b only understands ASCII word characters, so spell out a
+			// Unicode-aware word boundary instead.
+			pattern = `(?i)(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(word) + `(?:$|[^\p{L}\p{N}_])`
+		case WordMatchRegex:
+			pattern = word
+		default:
+			return nil, fmt.Errorf(
+				"invalid word match mode %q: must be one of %q, %q or %q",
+				mode,
+				WordMatchSubstring,
+				WordMatchWord,
+				WordMatchRegex,
+			)
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", word, err)
+		}
+		rules = append(rules, wordRule{word: word, re: re})
+	}
+
+	return rules, nil
+}
+
+// fieldText returns the text of an article field to match against. Content is
+// matched as plain text, so tag names, attributes and link URLs are ignored.
+func fieldText(article *models.Article, field string) string {
+	switch field {
+	case WordFieldTitle:
+		return article.Title
+	case WordFieldSummary:
+		return models.PlainText(article.Summary)
+	case WordFieldContent:
+		return models.PlainText(article.Content)
+	}
+	return ""
+}
+
+// match returns the first rule matching any of the filter's fields in the
+// article, and the field it matched in.
+func (f *WordFilter) match(article *models.Article, rules []wordRule) (string, string, bool) {
+	for _, field := range f.fields {
+		text := fieldText(article, field)
+		if text == "" {
+			continue
+		}
+
+		for _, rule := range rules {
+			if rule.re.MatchString(text) {
+				return rule.word, field, true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+// FilterArticles returns the articles that pass the filter, logging which
+// rule caused each other article to be filtered out.
+func (f *WordFilter) FilterArticles(articles []*models.Article) []*models.Article {
+	if f == nil || len(articles) == 0 || (len(f.deny) == 0 && len(f.allow) == 0) {
+		return articles
+	}
+
+	filtered := make([]*models.Article, 0, len(articles))
+	for _, article := range articles {
+		if word, field, ok := f.match(article, f.deny); ok {
+			log.Printf(
+				"Filtered out %q: matched denylisted word %q in %s",
+				article.Title,
+				word,
+				field,
+			)
+			continue
+		}
+
+		if len(f.allow) > 0 {
+			if _, _, ok := f.match(article, f.allow); !ok {
+				log.Printf("Filtered out %q: matched no allowlisted words", article.Title)
+				continue
+			}
+		}
+
+		filtered = append(filtered, article)
+	}
+
+	return filtered
+}
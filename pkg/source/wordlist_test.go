@@ -0,0 +1,112 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/shrik450/dijester/pkg/models"
+)
+
+func TestWordFilter_FilterArticles(t *testing.T) {
+	articles := []*models.Article{
+		{Title: "AI breakthrough", Content: "<p>Researchers announced a model.</p>"},
+		{Title: "Local news", Content: "<p>The mayor said the park will reopen.</p>"},
+		{
+			Title:   "Link heavy",
+			Content: `<p>Read <a href="https://example.com/crypto">more</a>.</p>`,
+			Summary: "Nothing to see",
+		},
+		{Title: "Release notes", Summary: "Go 1.24 is out", Content: "<p>Changes.</p>"},
+	}
+
+	tests := []struct {
+		name      string
+		denylist  []string
+		allowlist []string
+		mode      string
+		fields    []string
+		want      []string
+	}{
+		{
+			name:     "substring matches inside words",
+			denylist: []string{"ai"},
+			want:     []string{"Link heavy", "Release notes"},
+		},
+		{
+			name:     "whole word",
+			denylist: []string{"AI"},
+			mode:     WordMatchWord,
+			want:     []string{"Local news", "Link heavy", "Release notes"},
+		},
+		{
+			name:     "content ignores attributes and URLs",
+			denylist: []string{"crypto"},
+			want:     []string{"AI breakthrough", "Local news", "Link heavy", "Release notes"},
+		},
+		{
+			name:     "regex",
+			denylist: []string{`(?i)^(ai|local)\b`},
+			mode:     WordMatchRegex,
+			want:     []string{"Link heavy", "Release notes"},
+		},
+		{
+			name:     "field selection",
+			denylist: []string{"said"},
+			mode:     WordMatchWord,
+			fields:   []string{WordFieldTitle, WordFieldSummary},
+			want:     []string{"AI breakthrough", "Local news", "Link heavy", "Release notes"},
+		},
+		{
+			name:      "allowlist requires a match",
+			allowlist: []string{"go", "model"},
+			mode:      WordMatchWord,
+			want:      []string{"AI breakthrough", "Release notes"},
+		},
+		{
+			name:      "denylist takes precedence over allowlist",
+			denylist:  []string{"researchers"},
+			allowlist: []string{"go", "model"},
+			mode:      WordMatchWord,
+			want:      []string{"Release notes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewWordFilter(tt.denylist, tt.allowlist, tt.mode, tt.fields)
+			if err != nil {
+				t.Fatalf("NewWordFilter() returned error: %v", err)
+			}
+
+			got := f.FilterArticles(articles)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FilterArticles() returned %d articles, want %d", len(got), len(tt.want))
+			}
+			for i, article := range got {
+				if article.Title != tt.want[i] {
+					t.Errorf("article %d = %q, want %q", i, article.Title, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewWordFilter_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		denylist []string
+		mode     string
+		fields   []string
+	}{
+		{name: "invalid mode", denylist: []string{"a"}, mode: "fuzzy"},
+		{name: "invalid field", denylist: []string{"a"}, fields: []string{"author"}},
+		{name: "invalid regex", denylist: []string{"("}, mode: WordMatchRegex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWordFilter(tt.denylist, nil, tt.mode, tt.fields); err == nil {
+				t.Error("NewWordFilter() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// seenRetention is how long an article is remembered after it was last seen
+// in a source.
+const seenRetention = 90 * 24 * time.Hour
+
+// State records information about previous runs that needs to persist between
+// invocations, such as when each source last ran successfully.
+type State struct {
+	// LastRun maps source names to the time of their last successful run
+	LastRun map[string]time.Time `json:"last_run"`
+
+	// Seen maps article keys (usually URLs) to when they were first and last
+	// seen in a source
+	Seen map[string]SeenEntry `json:"seen"`
+}
+
+// SeenEntry records when an article was first and last seen.
+type SeenEntry struct {
+	// FirstSeen is when the article was first fetched from a source
+	FirstSeen time.Time `json:"first_seen"`
+
+	// LastSeen is when the article was most recently fetched from a source
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// New returns an empty state.
+func New() *State {
+	return &State{
+		LastRun: make(map[string]time.Time),
+		Seen:    make(map[string]SeenEntry),
+	}
+}
+
+// LoadFile loads state from a JSON file. If the file doesn't exist, an empty
+// state is returned.
+func LoadFile(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+
+	s := New()
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing state file: %w", err)
+	}
+
+	if s.LastRun == nil {
+		s.LastRun = make(map[string]time.Time)
+	}
+	if s.Seen == nil {
+		s.Seen = make(map[string]SeenEntry)
+	}
+
+	return s, nil
+}
+
+// SaveFile writes the state to a JSON file. The file is written to a temporary
+// location first and then renamed, so a failed write never corrupts existing
+// state.
+func (s *State) SaveFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".dijester-state-")
+	if err != nil {
+		return fmt.Errorf("creating temp state file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("replacing state file: %w", err)
+	}
+
+	return nil
+}
+
+// FirstSeenAt returns when the article with the given key was first seen,
+// recording now as both the first and last seen time if it's new.
+func (s *State) FirstSeenAt(key string, now time.Time) time.Time {
+	entry, ok := s.Seen[key]
+	if !ok {
+		entry.FirstSeen = now
+	}
+	entry.LastSeen = now
+	s.Seen[key] = entry
+
+	return entry.FirstSeen
+}
+
+// Prune forgets articles that haven't been seen for a while, so the state
+// file doesn't grow without bound.
+func (s *State) Prune(now time.Time) {
+	for key, entry := range s.Seen {
+		if now.Sub(entry.LastSeen) > seenRetention {
+			delete(s.Seen, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadFile_Missing(t *testing.T) {
+	s, err := LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadFile() returned error: %v", err)
+	}
+
+	if len(s.LastRun) != 0 || len(s.Seen) != 0 {
+		t.Errorf("LoadFile() of a missing file should return empty state, got %+v", s)
+	}
+}
+
+func TestSaveFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := New()
+	s.LastRun["hackernews"] = now
+	s.FirstSeenAt("https://example.com/1", now)
+
+	if err := s.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile() returned error: %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() returned error: %v", err)
+	}
+
+	if !loaded.LastRun["hackernews"].Equal(now) {
+		t.Errorf("LastRun = %v, want %v", loaded.LastRun["hackernews"], now)
+	}
+	if !loaded.Seen["https://example.com/1"].FirstSeen.Equal(now) {
+		t.Errorf("FirstSeen = %v, want %v", loaded.Seen["https://example.com/1"].FirstSeen, now)
+	}
+}
+
+func TestFirstSeenAt(t *testing.T) {
+	first := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	later := first.Add(24 * time.Hour)
+
+	s := New()
+	if got := s.FirstSeenAt("key", first); !got.Equal(first) {
+		t.Errorf("FirstSeenAt() = %v, want %v", got, first)
+	}
+	if got := s.FirstSeenAt("key", later); !got.Equal(first) {
+		t.Errorf("FirstSeenAt() on a known key = %v, want %v", got, first)
+	}
+	if got := s.Seen["key"].LastSeen; !got.Equal(later) {
+		t.Errorf("LastSeen = %v, want %v", got, later)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := New()
+	s.FirstSeenAt("old", now.Add(-2*seenRetention))
+	s.FirstSeenAt("recent", now.Add(-time.Hour))
+	s.Prune(now)
+
+	if _, ok := s.Seen["old"]; ok {
+		t.Error("Prune() should forget articles not seen within the retention period")
+	}
+	if _, ok := s.Seen["recent"]; !ok {
+		t.Error("Prune() should keep recently seen articles")
+	}
+}